@@ -4,26 +4,37 @@ import (
 	"encoding/json"
 	"io/ioutil"
 	"os"
-	"strings"
+	"time"
 
 	"github.com/probr/probr-sdk/utils"
 )
 
 // Scenario is used by scenario states to audit progress through each step
 type Scenario struct {
-	Name   string
-	Result string // Passed / Failed / Given Not Met
-	Tags   []string
-	Steps  map[int]*step
+	Name        string
+	Result      string // Passed / Failed / Given Not Met
+	Severity    Severity
+	Tags        []string
+	Steps       map[int]*step
+	Remediation *Remediation `json:",omitempty"` // Only populated if the scenario did not pass
+	guidance    *scenarioRemediation
+	nextKind    StepKind
+	activeStep  *step
+	stepStart   time.Time
 }
 
 type step struct {
 	Function    string
 	Name        string
-	Description string      // Long-form explanation of anything happening in the step
-	Result      string      // Passed / Failed
-	Error       string      // Log the error text
-	Payload     interface{} // Handles any values that are sent across the network
+	Text        string       `json:",omitempty"` // Gherkin step text, only populated when steps are audited automatically
+	Kind        StepKind     // Given / When / Then
+	Description string       // Long-form explanation of anything happening in the step
+	Result      string       // Passed / Failed / Skipped / Undefined
+	Error       string       // Log the error text
+	Payload     interface{}  // Handles any values that are sent across the network
+	Argument    interface{}  `json:",omitempty"` // Gherkin doc string or data table, only populated when steps are audited automatically
+	Duration    string       `json:",omitempty"` // Only populated when steps are audited automatically
+	Remediation *Remediation `json:",omitempty"` // Only populated if the step failed
 }
 
 func (e *Probe) Write() {
@@ -36,38 +47,52 @@ func (e *Probe) Write() {
 }
 
 // AuditScenarioStep sets description, payload, and pass/fail based on err parameter.
+// If steps are being audited automatically via BeginStep and EndStep, this only adds detail to the current step.
+// The step kind is taken from SetStepKeyword if it was called, otherwise the first step is treated as a given.
 // This function should be deferred to catch panic behavior, otherwise the audit will not be logged on panic
 func (p *Scenario) AuditScenarioStep(stepName, description string, payload interface{}, err error) {
-	stepFunctionName := utils.CallerName(2) // returns name if deferred and not panicking
+	p.audit(stepCallerName(), stepName, description, payload, err)
+}
+
+// stepCallerName returns the name of the step function that called one of the exported audit functions
+func stepCallerName() string {
+	stepFunctionName := utils.CallerName(3) // returns name if deferred and not panicking
 	switch stepFunctionName {
 	case "call":
-		stepFunctionName = utils.CallerName(1) // returns name if this function was not deferred in the caller
+		stepFunctionName = utils.CallerName(2) // returns name if the audit function was not deferred in the caller
 	case "gopanic":
-		stepFunctionName = utils.CallerName(3) // returns name if caller panicked and this function was deferred
+		stepFunctionName = utils.CallerName(4) // returns name if caller panicked and the audit function was deferred
 	}
-
-	p.audit(stepFunctionName, stepName, description, payload, err)
+	return stepFunctionName
 }
 
 func (p *Scenario) audit(functionName string, stepName string, description string, payload interface{}, err error) {
+	if p.activeStep != nil {
+		p.activeStep.addDetail(functionName, stepName, description, payload, err)
+		return
+	}
 	stepNumber := len(p.Steps) + 1
 	p.Steps[stepNumber] = &step{
 		Function:    functionName,
 		Name:        stepName,
+		Kind:        p.stepKind(),
 		Description: description,
 		Payload:     payload,
 	}
 	if err == nil {
 		p.Steps[stepNumber].Result = "Passed"
-		p.Result = "Passed"
 	} else {
-		p.Steps[stepNumber].Result = "Failed"
-		p.Steps[stepNumber].Error = strings.Replace(err.Error(), "[ERROR] ", "", -1)
-		if stepNumber == 1 {
-			// TODO: change to handle this in AuditScenarioGiven, then here do if step.IsGiven
-			p.Result = "Given Not Met" // First entry is always a 'given'; failures should be ignored
-		} else {
-			p.Result = "Failed" // First 'given' was met, but a subsequent step failed
-		}
+		p.Steps[stepNumber].setError(err)
+		p.Steps[stepNumber].Remediation = p.stepRemediation(functionName, stepName)
+	}
+	p.setResult()
+}
+
+func (p *Scenario) setResult() {
+	p.Result = p.computeResult()
+	if p.Result == "Passed" {
+		p.Remediation = nil
+	} else {
+		p.Remediation = p.scenarioGuidance()
 	}
 }
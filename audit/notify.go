@@ -0,0 +1,243 @@
+package audit
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/probr/probr-sdk/config"
+)
+
+// SignatureHeader contains the HMAC-SHA256 signature of the request body, formatted as "sha256=<hex>"
+const SignatureHeader = "X-Probr-Signature"
+
+// Notification is the data sent to webhooks when a run is complete
+type Notification struct {
+	Event         string
+	Status        string
+	ProbesPassed  int
+	ProbesFailed  int
+	ProbesSkipped int
+	RiskScore     int
+	FailedProbes  []string
+	NewFailures   []string // Probes that failed in this run but not in the previous run
+}
+
+// Notifier POSTs a Notification to a webhook when SetProbrStatus finalises a run
+type Notifier struct {
+	URL             string
+	Headers         map[string]string
+	Secret          string // If set, used to sign each request via SignatureHeader
+	Template        string // "generic" (default), "slack", "teams" or a text/template that renders JSON
+	OnlyNewFailures bool   // Only notify if a probe has failed that did not fail in the previous summary
+	PreviousSummary string // Path to the previous summary.json, defaults to WriteDirectory/summary.json
+	Retries         int
+	RetryDelay      time.Duration
+	Client          *http.Client // Defaults to a client that allows 30s for each request
+}
+
+// builtinTemplates provide payloads for common chat systems
+var builtinTemplates = map[string]string{
+	"slack": `{"text": {{ printf "Probr run %s. Risk score: %d. Failed probes: %s" .Status .RiskScore (join .FailedProbes ", ") | json }}}`,
+	"teams": `{"@type": "MessageCard", "@context": "https://schema.org/extensions", "summary": "Probr run complete", "text": {{ printf "Probr run %s. Risk score: %d. Failed probes: %s" .Status .RiskScore (join .FailedProbes ", ") | json }}}`,
+}
+
+// NewNotifiers creates notifiers from the provided config. Any invalid entries are returned as a single error.
+func NewNotifiers(opts []config.NotificationOpts) (notifiers []*Notifier, err error) {
+	var problems []string
+	for i, o := range opts {
+		n, nErr := newNotifier(o)
+		if nErr != nil {
+			problems = append(problems, fmt.Sprintf("Notifications[%d]: %v", i, nErr))
+			continue
+		}
+		notifiers = append(notifiers, n)
+	}
+	if len(problems) > 0 {
+		err = fmt.Errorf("invalid notification config: %v", problems)
+	}
+	return
+}
+
+func newNotifier(o config.NotificationOpts) (*Notifier, error) {
+	if o.URL == "" {
+		return nil, fmt.Errorf("URL is required")
+	}
+	retries, retryDelay, err := retrySettings(o.Retries, o.RetryDelay)
+	if err != nil {
+		return nil, err
+	}
+	client, err := httpClient(o.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	n := &Notifier{
+		URL:             o.URL,
+		Headers:         o.Headers,
+		Secret:          o.Secret,
+		Template:        o.Template,
+		OnlyNewFailures: o.OnlyNewFailures,
+		PreviousSummary: o.PreviousSummary,
+		Retries:         retries,
+		RetryDelay:      retryDelay,
+		Client:          client,
+	}
+	if _, err := n.template(); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// Notify sends the notification for the provided summary, unless OnlyNewFailures is set and there are none
+func (n *Notifier) Notify(s *SummaryState) error {
+	notification := s.notification(n.previousFailures(s.config().WriteDirectory))
+	if n.OnlyNewFailures && len(notification.NewFailures) == 0 {
+		log.Printf("[DEBUG] No new failures; skipping notification to %s", n.URL)
+		return nil
+	}
+	body, err := n.render(notification)
+	if err != nil {
+		return err
+	}
+	headers := make(map[string]string)
+	for k, v := range n.Headers {
+		headers[k] = v
+	}
+	if n.Secret != "" {
+		headers[SignatureHeader] = Sign(n.Secret, body)
+	}
+	return sendWithRetries(n.Client, http.MethodPost, n.URL, body, headers, n.Retries, n.RetryDelay)
+}
+
+// Sign returns the value of SignatureHeader for the provided body, for use by webhook receivers
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (n *Notifier) template() (*template.Template, error) {
+	text, ok := builtinTemplates[n.Template]
+	if n.Template == "" || n.Template == "generic" {
+		return nil, nil
+	} else if !ok {
+		text = n.Template
+	}
+	t, err := template.New("notification").Funcs(template.FuncMap{
+		"join": strings.Join,
+		"json": func(v interface{}) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+	}).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid notification template: %v", err)
+	}
+	return t, nil
+}
+
+func (n *Notifier) render(notification Notification) ([]byte, error) {
+	t, err := n.template()
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return json.Marshal(notification)
+	}
+	var b bytes.Buffer
+	if err = t.Execute(&b, notification); err != nil {
+		return nil, fmt.Errorf("failed to render notification template: %v", err)
+	}
+	return b.Bytes(), nil
+}
+
+// previousFailures reads the previous summary, returning nil if it is not available
+func (n *Notifier) previousFailures(writeDirectory string) map[string]bool {
+	path := n.PreviousSummary
+	if path == "" {
+		path = filepath.Join(writeDirectory, "summary.json")
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		log.Printf("[DEBUG] No previous summary found at %s; all failures are treated as new", path)
+		return nil
+	}
+	var previous limitedSummaryState
+	if err = json.Unmarshal(data, &previous); err != nil {
+		log.Printf("[WARN] Unable to parse previous summary at %s; all failures are treated as new: %v", path, err)
+		return nil
+	}
+	failures := make(map[string]bool)
+	for name, p := range previous.Probes {
+		if p.Result == "Failed" {
+			failures[name] = true
+		}
+	}
+	return failures
+}
+
+// AddNotifier adds a webhook to be notified when SetProbrStatus finalises the run.
+// If no notifiers are added, those configured in the config's Notifications are used.
+// Notifiers must be added before the run is finalised.
+func (s *SummaryState) AddNotifier(n *Notifier) {
+	s.notifiers = append(s.notifiers, n)
+}
+
+// getNotifiers resolves the notifiers from config once, the first time the run is finalised
+func (s *SummaryState) getNotifiers() []*Notifier {
+	s.notifiersOnce.Do(func() {
+		if s.notifiers != nil {
+			return
+		}
+		notifiers, err := NewNotifiers(s.config().Notifications)
+		if err != nil {
+			log.Printf("[ERROR] %v", err)
+		}
+		s.notifiers = append([]*Notifier{}, notifiers...)
+	})
+	return s.notifiers
+}
+
+func (s *SummaryState) notify() {
+	for _, n := range s.getNotifiers() {
+		if err := n.Notify(s); err != nil {
+			log.Printf("[ERROR] Failed to send notification to %s: %v", n.URL, err)
+		}
+	}
+}
+
+func (s *SummaryState) notification(previousFailures map[string]bool) Notification {
+	notification := Notification{
+		Event:         "run_complete",
+		Status:        s.Status,
+		ProbesPassed:  s.ProbesPassed,
+		ProbesFailed:  s.ProbesFailed,
+		ProbesSkipped: s.ProbesSkipped,
+		RiskScore:     s.RiskScore,
+		FailedProbes:  []string{},
+		NewFailures:   []string{},
+	}
+	for name, p := range s.Probes {
+		if p.Result != "Failed" {
+			continue
+		}
+		notification.FailedProbes = append(notification.FailedProbes, name)
+		if !previousFailures[name] {
+			notification.NewFailures = append(notification.NewFailures, name)
+		}
+	}
+	sort.Strings(notification.FailedProbes)
+	sort.Strings(notification.NewFailures)
+	return notification
+}
@@ -0,0 +1,98 @@
+package audit
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/probr/probr-sdk/config"
+)
+
+// failedSummary creates a summary in which the named probe has failed
+func failedSummary(probeName string) *SummaryState {
+	s := NewSummaryState("test")
+	s.AddSink(&StdoutSink{Writer: ioutil.Discard})
+	p := s.GetProbeLog(probeName)
+	scenario := p.InitializeAuditor("scenario", nil)
+	scenario.AuditScenarioGiven("given", "", nil, nil)
+	scenario.AuditScenarioThen("then", "", nil, errors.New("failed"))
+	s.ProbeComplete(probeName)
+	return &s
+}
+
+func TestNotifier_Notify(t *testing.T) {
+	var received []byte
+	var signature string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received, _ = ioutil.ReadAll(r.Body)
+		signature = r.Header.Get(SignatureHeader)
+	}))
+	defer server.Close()
+
+	notifiers, err := NewNotifiers([]config.NotificationOpts{{URL: server.URL, Secret: "secret", PreviousSummary: "missing.json"}})
+	if err != nil {
+		t.Fatalf("NewNotifiers() error = %v", err)
+	}
+	s := failedSummary("failing_probe")
+	s.AddNotifier(notifiers[0])
+	s.SetProbrStatus()
+
+	var notification Notification
+	if err := json.Unmarshal(received, &notification); err != nil {
+		t.Fatalf("Failed to parse notification: %v (%s)", err, received)
+	}
+	if len(notification.NewFailures) != 1 || notification.NewFailures[0] != "failing_probe" {
+		t.Errorf("Unexpected NewFailures: %v", notification.NewFailures)
+	}
+	if signature != Sign("secret", received) {
+		t.Errorf("Signature = %s, Expected: %s", signature, Sign("secret", received))
+	}
+}
+
+func TestNotifier_OnlyNewFailures(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer server.Close()
+
+	previous := filepath.Join(t.TempDir(), "summary.json")
+	s := failedSummary("failing_probe")
+	s.SetProbrStatus()
+	ioutil.WriteFile(previous, s.summary(), 0644)
+
+	s.AddNotifier(&Notifier{URL: server.URL, OnlyNewFailures: true, PreviousSummary: previous})
+	s.SetProbrStatus()
+	if requests != 0 {
+		t.Errorf("Expected no notification when failures are unchanged, found %d", requests)
+	}
+
+	s = failedSummary("another_probe")
+	s.AddNotifier(&Notifier{URL: server.URL, OnlyNewFailures: true, PreviousSummary: previous})
+	s.SetProbrStatus()
+	if requests != 1 {
+		t.Errorf("Expected a notification for a new failure, found %d", requests)
+	}
+}
+
+func TestNotifier_Templates(t *testing.T) {
+	n := Notification{Status: "Complete - 0/1 Succeeded (0 Skipped)", FailedProbes: []string{"a \"quoted\" probe"}}
+	for _, name := range []string{"slack", "teams", `{"status": {{ json .Status }}}`} {
+		body, err := (&Notifier{Template: name}).render(n)
+		if err != nil {
+			t.Errorf("render(%s) error = %v", name, err)
+			continue
+		}
+		if !json.Valid(body) || !strings.Contains(string(body), "0/1 Succeeded") {
+			t.Errorf("render(%s) produced unexpected payload: %s", name, body)
+		}
+	}
+	if _, err := NewNotifiers([]config.NotificationOpts{{URL: "http://localhost", Template: "{{ .Missing"}}); err == nil {
+		t.Errorf("Expected an error for an invalid template")
+	}
+}
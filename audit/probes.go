@@ -1,6 +1,8 @@
 package audit
 
 import (
+	"time"
+
 	"github.com/cucumber/messages-go/v10"
 )
 
@@ -9,30 +11,39 @@ type Probe struct {
 	name               string
 	Meta               map[string]interface{}
 	Path               string
+	LogPath            string // Logs emitted while the probe ran, written alongside the audit
 	ScenariosAttempted int
 	ScenariosSucceeded int
 	ScenariosFailed    int
 	GivenNotMet        int
+	RiskScore          int
 	Result             string
+	StartTime          time.Time
+	EndTime            time.Time
 	Scenarios          map[int]*Scenario
+	remediations       map[string]*scenarioRemediation
 }
 
 type limitedProbe struct {
 	Meta               map[string]interface{} `json:"Meta"`
 	Path               string                 `json:"Path"`
+	LogPath            string                 `json:"LogPath,omitempty"`
 	ScenariosAttempted int                    `json:"ScenariosAttempted"`
 	ScenariosSucceeded int                    `json:"ScenariosSucceeded"`
 	ScenariosFailed    int                    `json:"ScenariosFailed"`
 	GivenNotMet        int                    `json:"GivenNotMet"`
+	RiskScore          int                    `json:"RiskScore"`
 	Result             string                 `json:"Result"`
 }
 
-// countResults stores the current total number of failures as e.ScenariosFailed. Run at probe end
+// countResults stores the current total number of failures as e.ScenariosFailed
+// and the weighted severity of those failures as e.RiskScore. Run at probe end
 func (e *Probe) countResults() {
 	e.ScenariosAttempted = len(e.Scenarios)
 	for _, v := range e.Scenarios {
 		if v.Result == "Failed" {
 			e.ScenariosFailed = e.ScenariosFailed + 1
+			e.RiskScore = e.RiskScore + v.Severity.Weight()
 		} else if v.Result == "Passed" {
 			e.ScenariosSucceeded = e.ScenariosSucceeded + 1
 		} else if v.Result == "Given Not Met" {
@@ -41,6 +52,14 @@ func (e *Probe) countResults() {
 	}
 }
 
+// Duration returns the time taken to execute the probe, or zero if it has not both started and completed
+func (e *Probe) Duration() time.Duration {
+	if e.StartTime.IsZero() || e.EndTime.IsZero() {
+		return 0
+	}
+	return e.EndTime.Sub(e.StartTime)
+}
+
 // InitializeAuditor creates a new audit entry for the specified scenario
 func (e *Probe) InitializeAuditor(name string, tags []*messages.Pickle_PickleTag) *Scenario {
 	if e.Scenarios == nil {
@@ -52,9 +71,34 @@ func (e *Probe) InitializeAuditor(name string, tags []*messages.Pickle_PickleTag
 		t = append(t, tag.Name)
 	}
 	e.Scenarios[i] = &Scenario{
-		Name:  name,
-		Steps: make(map[int]*step),
-		Tags:  t,
+		Name:     name,
+		Severity: e.scenarioSeverity(t),
+		Steps:    make(map[int]*step),
+		Tags:     t,
+	}
+	if r, ok := e.remediations[name]; ok {
+		e.Scenarios[i].guidance = r.copy()
 	}
 	return e.Scenarios[i]
 }
+
+// scenarioSeverity prefers a severity tag on the scenario, then the probe meta, then DefaultSeverity
+func (e *Probe) scenarioSeverity(tags []string) Severity {
+	if s, ok := severityFromTags(tags); ok {
+		return s
+	}
+	if s, ok := severityFromMeta(e.Meta); ok {
+		return s
+	}
+	return DefaultSeverity
+}
+
+// HighestFailedSeverity returns the most severe level among the failed scenarios in this probe
+func (e *Probe) HighestFailedSeverity() (highest Severity) {
+	for _, v := range e.Scenarios {
+		if v.Result == "Failed" && v.Severity > highest {
+			highest = v.Severity
+		}
+	}
+	return
+}
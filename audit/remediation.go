@@ -0,0 +1,120 @@
+package audit
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Remediation provides guidance to the user when a scenario or step does not pass
+type Remediation struct {
+	Text  string   `yaml:"Text" json:"Text"`
+	Links []string `yaml:"Links" json:"Links,omitempty"`
+}
+
+// scenarioRemediation is the side-car YAML format for a single scenario, keyed by scenario name
+type scenarioRemediation struct {
+	Remediation `yaml:",inline"`
+	Steps       map[string]Remediation `yaml:"Steps"`
+}
+
+// LoadRemediations reads a side-car YAML file containing remediation guidance keyed by scenario name.
+// Step guidance may be nested beneath each scenario using the step name or function name as the key:
+//
+//	Scenario name:
+//	  Text: Guidance for the whole scenario
+//	  Links: [https://example.com]
+//	  Steps:
+//	    step name:
+//	      Text: Guidance for a single step
+func (e *Probe) LoadRemediations(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read remediation file '%s': %v", path, err)
+	}
+	var remediations map[string]scenarioRemediation
+	err = yaml.Unmarshal(data, &remediations)
+	if err != nil {
+		return fmt.Errorf("failed to parse remediation file '%s': %v", path, err)
+	}
+	for scenarioName, r := range remediations {
+		if r.Text != "" || len(r.Links) > 0 {
+			e.RegisterRemediation(scenarioName, r.Text, r.Links...)
+		}
+		for stepName, s := range r.Steps {
+			e.RegisterStepRemediation(scenarioName, stepName, s.Text, s.Links...)
+		}
+	}
+	return nil
+}
+
+// RegisterRemediation stores guidance to be attached to the named scenario if it does not pass
+func (e *Probe) RegisterRemediation(scenarioName, text string, links ...string) {
+	e.scenarioRemediations(scenarioName).Remediation = Remediation{Text: text, Links: links}
+}
+
+// RegisterStepRemediation stores guidance to be attached to a step within the named scenario if that step fails
+func (e *Probe) RegisterStepRemediation(scenarioName, stepName, text string, links ...string) {
+	r := e.scenarioRemediations(scenarioName)
+	r.Steps[stepName] = Remediation{Text: text, Links: links}
+}
+
+func (e *Probe) scenarioRemediations(scenarioName string) *scenarioRemediation {
+	if e.remediations == nil {
+		e.remediations = make(map[string]*scenarioRemediation)
+	}
+	if e.remediations[scenarioName] == nil {
+		e.remediations[scenarioName] = &scenarioRemediation{Steps: make(map[string]Remediation)}
+	}
+	return e.remediations[scenarioName]
+}
+
+// copy prevents changes made to one scenario's guidance from affecting other scenarios with the same name
+func (r *scenarioRemediation) copy() *scenarioRemediation {
+	c := &scenarioRemediation{Remediation: r.Remediation, Steps: make(map[string]Remediation)}
+	for k, v := range r.Steps {
+		c.Steps[k] = v
+	}
+	return c
+}
+
+// SetRemediation stores guidance to be included in the audit if this scenario does not pass
+func (p *Scenario) SetRemediation(text string, links ...string) {
+	p.remediations().Remediation = Remediation{Text: text, Links: links}
+}
+
+// SetStepRemediation stores guidance to be included in the audit if the named step fails.
+// The step may be identified by either the step name or the step function name.
+func (p *Scenario) SetStepRemediation(stepName, text string, links ...string) {
+	p.remediations().Steps[stepName] = Remediation{Text: text, Links: links}
+}
+
+func (p *Scenario) remediations() *scenarioRemediation {
+	if p.guidance == nil {
+		p.guidance = &scenarioRemediation{Steps: make(map[string]Remediation)}
+	}
+	return p.guidance
+}
+
+// stepRemediation finds the guidance registered for a step, falling back to the scenario guidance
+func (p *Scenario) stepRemediation(functionName, stepName string) *Remediation {
+	if p.guidance == nil {
+		return nil
+	}
+	for _, key := range []string{stepName, functionName} {
+		if r, ok := p.guidance.Steps[key]; ok {
+			return &r
+		}
+	}
+	return p.scenarioGuidance()
+}
+
+// scenarioGuidance returns the guidance registered for the scenario as a whole, if any
+func (p *Scenario) scenarioGuidance() *Remediation {
+	if p.guidance == nil || (p.guidance.Text == "" && len(p.guidance.Links) == 0) {
+		return nil
+	}
+	r := p.guidance.Remediation
+	return &r
+}
@@ -0,0 +1,67 @@
+package audit
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestProbe_LoadRemediations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "remediations.yaml")
+	data := []byte(`
+Ensure storage is encrypted:
+  Text: Enable encryption at rest
+  Links: [https://example.com/encryption]
+  Steps:
+    a storage account exists:
+      Text: Create a storage account before running this probe
+`)
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p := &Probe{}
+	if err := p.LoadRemediations(path); err != nil {
+		t.Fatalf("LoadRemediations() error = %v", err)
+	}
+	if err := p.LoadRemediations(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Errorf("LoadRemediations() expected error for missing file")
+	}
+
+	s := p.InitializeAuditor("Ensure storage is encrypted", nil)
+	s.AuditScenarioStep("a storage account exists", "", nil, errors.New("not found"))
+	if s.Steps[1].Remediation == nil || s.Steps[1].Remediation.Text != "Create a storage account before running this probe" {
+		t.Errorf("Expected step remediation to be attached, found %v", s.Steps[1].Remediation)
+	}
+	if s.Remediation == nil || s.Remediation.Links[0] != "https://example.com/encryption" {
+		t.Errorf("Expected scenario remediation to be attached, found %v", s.Remediation)
+	}
+
+	other := p.InitializeAuditor("Some other scenario", nil)
+	other.AuditScenarioStep("step", "", nil, errors.New("failed"))
+	if other.Remediation != nil || other.Steps[1].Remediation != nil {
+		t.Errorf("Expected no remediation for an unregistered scenario")
+	}
+}
+
+func TestScenario_SetStepRemediation(t *testing.T) {
+	p := &Probe{}
+	s := p.InitializeAuditor("scenario", nil)
+	s.SetRemediation("scenario guidance")
+	s.SetStepRemediation("second step", "step guidance")
+
+	s.AuditScenarioStep("first step", "", nil, nil)
+	s.AuditScenarioStep("second step", "", nil, errors.New("failed"))
+	s.AuditScenarioStep("third step", "", nil, errors.New("failed"))
+
+	if s.Steps[1].Remediation != nil {
+		t.Errorf("Passed step should not include remediation")
+	}
+	if s.Steps[2].Remediation == nil || s.Steps[2].Remediation.Text != "step guidance" {
+		t.Errorf("Expected step guidance, found %v", s.Steps[2].Remediation)
+	}
+	if s.Steps[3].Remediation == nil || s.Steps[3].Remediation.Text != "scenario guidance" {
+		t.Errorf("Expected fallback to scenario guidance, found %v", s.Steps[3].Remediation)
+	}
+}
@@ -0,0 +1,108 @@
+package audit
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Severity describes the impact of a scenario failing, e.g. Low, Medium, High and Critical
+type Severity int
+
+// Severity enumeration for the Severity type.
+const (
+	SeverityNone Severity = iota
+	SeverityLow
+	SeverityMedium
+	SeverityHigh
+	SeverityCritical
+)
+
+// DefaultSeverity is applied to scenarios that have no severity tag and no severity in the probe meta
+const DefaultSeverity = SeverityMedium
+
+// severityTagPrefix is used to identify severity tags on a scenario, e.g. '@severity-high'
+const severityTagPrefix = "@severity-"
+
+// severityMetaKey is the probe meta key that may be used to set a severity for all scenarios in a probe
+const severityMetaKey = "severity"
+
+var severityWeights = map[Severity]int{
+	SeverityNone:     0,
+	SeverityLow:      1,
+	SeverityMedium:   3,
+	SeverityHigh:     7,
+	SeverityCritical: 10,
+}
+
+var severityNames = [...]string{"None", "Low", "Medium", "High", "Critical"}
+
+func (s Severity) String() string {
+	if s < 0 || int(s) >= len(severityNames) {
+		return fmt.Sprintf("Severity(%d)", s)
+	}
+	return severityNames[s]
+}
+
+// Weight returns the value that a failure at this severity contributes to a risk score
+func (s Severity) Weight() int {
+	return severityWeights[s]
+}
+
+// MarshalText allows the severity to be printed by name in the audit output
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText allows the severity to be read by name from the audit output
+func (s *Severity) UnmarshalText(text []byte) (err error) {
+	*s, err = ParseSeverity(string(text))
+	return
+}
+
+// ParseSeverity converts a case-insensitive severity name into a Severity.
+// An empty string is parsed as SeverityNone.
+func ParseSeverity(name string) (Severity, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "none":
+		return SeverityNone, nil
+	case "low":
+		return SeverityLow, nil
+	case "medium":
+		return SeverityMedium, nil
+	case "high":
+		return SeverityHigh, nil
+	case "critical":
+		return SeverityCritical, nil
+	}
+	return SeverityNone, fmt.Errorf("unknown severity '%s'; expected one of none, low, medium, high or critical", name)
+}
+
+// severityFromTags returns the highest severity found in tags such as '@severity-high'
+func severityFromTags(tags []string) (severity Severity, found bool) {
+	for _, tag := range tags {
+		if !strings.HasPrefix(tag, severityTagPrefix) {
+			continue
+		}
+		s, err := ParseSeverity(strings.TrimPrefix(tag, severityTagPrefix))
+		if err != nil {
+			continue
+		}
+		found = true
+		if s > severity {
+			severity = s
+		}
+	}
+	return
+}
+
+// severityFromMeta returns the severity stored in probe meta, if it is present and valid
+func severityFromMeta(meta map[string]interface{}) (severity Severity, found bool) {
+	switch v := meta[severityMetaKey].(type) {
+	case Severity:
+		return v, true
+	case string:
+		s, err := ParseSeverity(v)
+		return s, err == nil && v != ""
+	}
+	return
+}
@@ -0,0 +1,104 @@
+package audit
+
+import (
+	"testing"
+)
+
+func TestParseSeverity(t *testing.T) {
+	tests := []struct {
+		testName string
+		input    string
+		expected Severity
+		wantErr  bool
+	}{
+		{testName: "Empty value should be None", input: "", expected: SeverityNone},
+		{testName: "Lower case value should parse", input: "high", expected: SeverityHigh},
+		{testName: "Mixed case value should parse", input: "Critical", expected: SeverityCritical},
+		{testName: "Unknown value should error", input: "urgent", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			got, err := ParseSeverity(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseSeverity() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.expected {
+				t.Errorf("ParseSeverity() = %v, Expected: %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSeverity_String(t *testing.T) {
+	tests := map[Severity]string{
+		SeverityNone:     "None",
+		SeverityCritical: "Critical",
+		Severity(-1):     "Severity(-1)",
+		Severity(5):      "Severity(5)",
+	}
+	for s, expected := range tests {
+		if s.String() != expected {
+			t.Errorf("String() = %s, Expected: %s", s.String(), expected)
+		}
+	}
+}
+
+func TestScenarioSeverity(t *testing.T) {
+	tests := []struct {
+		testName string
+		tags     []string
+		meta     map[string]interface{}
+		expected Severity
+	}{
+		{
+			testName: "Tag should take priority over probe meta",
+			tags:     []string{"@probes/example", "@severity-low"},
+			meta:     map[string]interface{}{"severity": "critical"},
+			expected: SeverityLow,
+		},
+		{
+			testName: "Highest tag should be used when multiple are provided",
+			tags:     []string{"@severity-low", "@severity-high"},
+			expected: SeverityHigh,
+		},
+		{
+			testName: "Probe meta should be used when no tag is provided",
+			meta:     map[string]interface{}{"severity": "critical"},
+			expected: SeverityCritical,
+		},
+		{
+			testName: "Default should be used when nothing is provided",
+			expected: DefaultSeverity,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			p := &Probe{Meta: tt.meta}
+			if got := p.scenarioSeverity(tt.tags); got != tt.expected {
+				t.Errorf("scenarioSeverity() = %v, Expected: %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSummaryState_ExitCode(t *testing.T) {
+	s := NewSummaryState("test")
+	p := s.GetProbeLog("probe")
+	p.Scenarios = map[int]*Scenario{
+		1: {Result: "Failed", Severity: SeverityMedium},
+		2: {Result: "Passed", Severity: SeverityCritical},
+		3: {Result: "Failed", Severity: SeverityLow},
+	}
+	s.completeProbe(p)
+
+	if s.RiskScore != SeverityMedium.Weight()+SeverityLow.Weight() {
+		t.Errorf("RiskScore = %v, Expected: %v", s.RiskScore, SeverityMedium.Weight()+SeverityLow.Weight())
+	}
+	if got := s.ExitCode(SeverityHigh); got != 0 {
+		t.Errorf("ExitCode(High) = %v, Expected: 0", got)
+	}
+	if got := s.ExitCode(SeverityMedium); got != 1 {
+		t.Errorf("ExitCode(Medium) = %v, Expected: 1", got)
+	}
+}
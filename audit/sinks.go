@@ -0,0 +1,267 @@
+package audit
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/probr/probr-sdk/config"
+	"github.com/probr/probr-sdk/utils"
+)
+
+// Sink is a destination for run summaries and per-probe audits
+type Sink interface {
+	WriteSummary(summary []byte) error
+	WriteProbe(name string, audit []byte) error
+}
+
+// FileSink writes summary.json and audit/<probe>.json to a local directory
+type FileSink struct {
+	Directory string // Defaults to the WriteDirectory of the summary it is used by, or config.GlobalConfig if used alone
+}
+
+// StdoutSink writes summaries and probe audits to stdout, or to Writer if it is set
+type StdoutSink struct {
+	Writer io.Writer
+}
+
+// HTTPSink POSTs summaries and probe audits to a single URL.
+// The document type and probe name are provided in the X-Probr-Document and X-Probr-Probe headers.
+type HTTPSink struct {
+	URL        string
+	Headers    map[string]string
+	Retries    int
+	RetryDelay time.Duration
+	Client     *http.Client // Defaults to a client that allows 30s for each request
+}
+
+// ObjectStoreSink PUTs summaries and probe audits as objects to an object store endpoint,
+// using the same layout as FileSink beneath the bucket and prefix.
+// Requests are not signed, so the endpoint must accept static authentication set via Headers or URL, such as
+// an Azure Blob Storage SAS token (with the header x-ms-blob-type: BlockBlob) or a bucket that allows
+// unauthenticated writes. S3 requires each request to be signed, so it is not supported.
+type ObjectStoreSink struct {
+	URL        string
+	Bucket     string
+	Prefix     string
+	Headers    map[string]string
+	Retries    int
+	RetryDelay time.Duration
+	Client     *http.Client // Defaults to a client that allows 30s for each request
+}
+
+const (
+	defaultSinkRetries    = 3
+	defaultSinkRetryDelay = time.Second
+	defaultSinkTimeout    = 30 * time.Second
+)
+
+// defaultClient is used by remote sinks and notifiers without a Client, as http.DefaultClient never times out
+var defaultClient = &http.Client{Timeout: defaultSinkTimeout}
+
+// NewSinks creates sinks from the provided config. Any invalid entries are returned as a single error.
+func NewSinks(opts []config.SinkOpts) (sinks []Sink, err error) {
+	var problems []string
+	for i, o := range opts {
+		sink, sinkErr := newSink(o)
+		if sinkErr != nil {
+			problems = append(problems, fmt.Sprintf("Sinks[%d]: %v", i, sinkErr))
+			continue
+		}
+		sinks = append(sinks, sink)
+	}
+	if len(problems) > 0 {
+		err = fmt.Errorf("invalid sink config: %v", problems)
+	}
+	return
+}
+
+func newSink(o config.SinkOpts) (Sink, error) {
+	retries, retryDelay, err := retrySettings(o.Retries, o.RetryDelay)
+	if err != nil {
+		return nil, err
+	}
+	client, err := httpClient(o.Timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	switch o.Type {
+	case "file":
+		return &FileSink{Directory: o.Path}, nil
+	case "stdout":
+		return &StdoutSink{}, nil
+	case "http":
+		if o.URL == "" {
+			return nil, fmt.Errorf("URL is required for http sinks")
+		}
+		return &HTTPSink{URL: o.URL, Headers: o.Headers, Retries: retries, RetryDelay: retryDelay, Client: client}, nil
+	case "objectstore":
+		if o.URL == "" {
+			return nil, fmt.Errorf("URL is required for objectstore sinks")
+		}
+		return &ObjectStoreSink{URL: o.URL, Bucket: o.Bucket, Prefix: o.Prefix, Headers: o.Headers, Retries: retries, RetryDelay: retryDelay, Client: client}, nil
+	}
+	return nil, fmt.Errorf("unknown sink type '%s'; expected file, stdout, http or objectstore", o.Type)
+}
+
+// retrySettings applies the defaults for remote sinks and notifications
+func retrySettings(retries int, delay string) (int, time.Duration, error) {
+	retryDelay := defaultSinkRetryDelay
+	if delay != "" {
+		d, err := time.ParseDuration(delay)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid RetryDelay '%s': %v", delay, err)
+		}
+		retryDelay = d
+	}
+	if retries == 0 {
+		retries = defaultSinkRetries
+	} else if retries < 0 {
+		retries = 0 // Negative values disable retries
+	}
+	return retries, retryDelay, nil
+}
+
+// httpClient returns a client that allows the configured duration for each request, or the default if it is empty
+func httpClient(timeout string) (*http.Client, error) {
+	if timeout == "" {
+		return defaultClient, nil
+	}
+	d, err := time.ParseDuration(timeout)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Timeout '%s': %v", timeout, err)
+	}
+	return &http.Client{Timeout: d}, nil
+}
+
+// WriteSummary writes summary.json to the sink directory, which defaults to the configured WriteDirectory
+func (f *FileSink) WriteSummary(summary []byte) error {
+	return f.write(filepath.Join(f.directory(), "summary.json"), summary)
+}
+
+// WriteProbe writes audit/<name>.json to the sink directory, which defaults to the configured WriteDirectory
+func (f *FileSink) WriteProbe(name string, audit []byte) error {
+	return f.write(filepath.Join(f.directory(), "audit", name+".json"), audit)
+}
+
+func (f *FileSink) directory() string {
+	if f.Directory == "" {
+		return config.GlobalConfig.WriteDirectory
+	}
+	return f.Directory
+}
+
+func (f *FileSink) write(path string, data []byte) error {
+	if !utils.WriteAllowed(path) {
+		return fmt.Errorf("unable to write to %s", path)
+	}
+	return ioutil.WriteFile(path, data, 0755)
+}
+
+// WriteSummary prints the summary
+func (o *StdoutSink) WriteSummary(summary []byte) error {
+	_, err := fmt.Fprintf(o.writer(), "%s\n", summary)
+	return err
+}
+
+// WriteProbe prints the probe audit
+func (o *StdoutSink) WriteProbe(name string, audit []byte) error {
+	_, err := fmt.Fprintf(o.writer(), "%s\n", audit)
+	return err
+}
+
+func (o *StdoutSink) writer() io.Writer {
+	if o.Writer == nil {
+		return os.Stdout
+	}
+	return o.Writer
+}
+
+// WriteSummary POSTs the summary to the sink URL
+func (h *HTTPSink) WriteSummary(summary []byte) error {
+	return h.post(summary, map[string]string{"X-Probr-Document": "summary"})
+}
+
+// WriteProbe POSTs the probe audit to the sink URL
+func (h *HTTPSink) WriteProbe(name string, audit []byte) error {
+	return h.post(audit, map[string]string{"X-Probr-Document": "audit", "X-Probr-Probe": name})
+}
+
+func (h *HTTPSink) post(data []byte, documentHeaders map[string]string) error {
+	headers := make(map[string]string)
+	for _, set := range []map[string]string{h.Headers, documentHeaders} {
+		for k, v := range set {
+			headers[k] = v
+		}
+	}
+	return sendWithRetries(h.Client, http.MethodPost, h.URL, data, headers, h.Retries, h.RetryDelay)
+}
+
+// WriteSummary PUTs summary.json beneath the bucket and prefix
+func (s *ObjectStoreSink) WriteSummary(summary []byte) error {
+	return s.put("summary.json", summary)
+}
+
+// WriteProbe PUTs audit/<name>.json beneath the bucket and prefix
+func (s *ObjectStoreSink) WriteProbe(name string, audit []byte) error {
+	return s.put(path.Join("audit", name+".json"), audit)
+}
+
+func (s *ObjectStoreSink) put(key string, data []byte) error {
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		return fmt.Errorf("invalid object store URL '%s': %v", s.URL, err)
+	}
+	u.Path = path.Join("/", u.Path, s.Bucket, s.Prefix, key)
+	return sendWithRetries(s.Client, http.MethodPut, u.String(), data, s.Headers, s.Retries, s.RetryDelay)
+}
+
+// sendWithRetries retries network errors and 5xx responses, doubling the delay after each attempt
+func sendWithRetries(client *http.Client, method, target string, data []byte, headers map[string]string, retries int, delay time.Duration) (err error) {
+	if client == nil {
+		client = defaultClient
+	}
+	for attempt := 0; attempt <= retries; attempt++ {
+		if attempt > 0 {
+			log.Printf("[WARN] Retrying %s to %s after error: %v", method, target, err)
+			time.Sleep(delay)
+			delay = delay * 2
+		}
+		var retry bool
+		retry, err = send(client, method, target, data, headers)
+		if err == nil || !retry {
+			return
+		}
+	}
+	return
+}
+
+func send(client *http.Client, method, target string, data []byte, headers map[string]string) (retry bool, err error) {
+	req, err := http.NewRequest(method, target, bytes.NewReader(data))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+		return resp.StatusCode >= 500, fmt.Errorf("%s %s returned %s: %s", method, target, resp.Status, body)
+	}
+	return false, nil
+}
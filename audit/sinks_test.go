@@ -0,0 +1,149 @@
+package audit
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/probr/probr-sdk/config"
+)
+
+// recordingServer stores each request body by method and path, failing the first 'failures' requests
+type recordingServer struct {
+	sync.Mutex
+	failures int
+	received map[string]string
+}
+
+func (r *recordingServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.Lock()
+	defer r.Unlock()
+	if r.failures > 0 {
+		r.failures--
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	body, _ := ioutil.ReadAll(req.Body)
+	key := strings.TrimSpace(strings.Join([]string{req.Method, req.URL.Path, req.Header.Get("X-Probr-Document"), req.Header.Get("X-Probr-Probe")}, " "))
+	r.received[key] = string(body)
+}
+
+func TestSummaryState_Sinks(t *testing.T) {
+	recorder := &recordingServer{failures: 1, received: make(map[string]string)}
+	server := httptest.NewServer(recorder)
+	defer server.Close()
+
+	sinks, err := NewSinks([]config.SinkOpts{
+		{Type: "http", URL: server.URL + "/results", Headers: map[string]string{"Authorization": "token"}, RetryDelay: "1ms"},
+		{Type: "objectstore", URL: server.URL, Bucket: "bucket", Prefix: "run-1", RetryDelay: "1ms"},
+	})
+	if err != nil {
+		t.Fatalf("NewSinks() error = %v", err)
+	}
+	var stdout bytes.Buffer
+
+	s := NewSummaryState("test")
+	for _, sink := range sinks {
+		s.AddSink(sink)
+	}
+	s.AddSink(&StdoutSink{Writer: &stdout})
+
+	p := s.GetProbeLog("example_probe")
+	p.InitializeAuditor("scenario", nil).AuditScenarioStep("step", "", nil, nil)
+	s.ProbeComplete("example_probe")
+	s.SetProbrStatus()
+	s.WriteSummary()
+
+	expected := []string{
+		"POST /results audit example_probe",
+		"POST /results summary",
+		"PUT /bucket/run-1/audit/example_probe.json",
+		"PUT /bucket/run-1/summary.json",
+	}
+	for _, key := range expected {
+		if _, ok := recorder.received[key]; !ok {
+			t.Errorf("Expected request '%s' to be received; found %v", key, recorder.received)
+		}
+	}
+	if !strings.Contains(recorder.received["POST /results summary"], "1/1 Succeeded") {
+		t.Errorf("Unexpected summary delivered: %s", recorder.received["POST /results summary"])
+	}
+	if !strings.Contains(stdout.String(), `"ScenariosSucceeded": 1`) {
+		t.Errorf("Expected probe audit to be printed; found %s", stdout.String())
+	}
+}
+
+func TestSummaryState_ConcurrentWrites(t *testing.T) {
+	s := NewSummaryState("test")
+	s.SetConfig(&config.GlobalOpts{WriteDirectory: t.TempDir()})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.WriteSummary()
+		}()
+	}
+	wg.Wait()
+	if sinks := s.getSinks(); len(sinks) != 1 {
+		t.Errorf("Expected the default sink to be resolved once; found %v", sinks)
+	}
+}
+
+func TestHTTPSink_NoRetryOnClientError(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	sink := &HTTPSink{URL: server.URL, Retries: 3, RetryDelay: time.Millisecond}
+	if err := sink.WriteSummary([]byte("{}")); err == nil {
+		t.Errorf("Expected an error for a 400 response")
+	}
+	if requests != 1 {
+		t.Errorf("Expected 1 request, found %d", requests)
+	}
+}
+
+func TestHTTPSink_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	sinks, err := NewSinks([]config.SinkOpts{{Type: "http", URL: server.URL, Retries: -1, Timeout: "20ms"}})
+	if err != nil {
+		t.Fatalf("NewSinks() error = %v", err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- sinks[0].WriteSummary([]byte("{}")) }()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Errorf("Expected an error when the request times out")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected the request to time out")
+	}
+}
+
+func TestNewSinks(t *testing.T) {
+	_, err := NewSinks([]config.SinkOpts{{Type: "ftp"}, {Type: "http"}, {Type: "file", RetryDelay: "soon"}, {Type: "http", URL: "http://localhost", Timeout: "later"}})
+	if err == nil || !strings.Contains(err.Error(), "Sinks[0]") || !strings.Contains(err.Error(), "Sinks[2]") || !strings.Contains(err.Error(), "Sinks[3]") {
+		t.Errorf("Expected all invalid sinks to be reported; found %v", err)
+	}
+	sinks, err := NewSinks([]config.SinkOpts{{Type: "http", URL: "http://localhost"}})
+	if err != nil || sinks[0].(*HTTPSink).Client.Timeout != defaultSinkTimeout {
+		t.Errorf("Expected http sinks to default to a %v timeout; found %+v, %v", defaultSinkTimeout, sinks, err)
+	}
+}
@@ -0,0 +1,193 @@
+package audit
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// StepKind describes the purpose of a step within a scenario, e.g. Given, When or Then
+type StepKind int
+
+// StepKind enumeration for the StepKind type.
+const (
+	UnknownStep StepKind = iota
+	GivenStep
+	WhenStep
+	ThenStep
+)
+
+var stepKindNames = [...]string{"Unknown", "Given", "When", "Then"}
+
+func (k StepKind) String() string {
+	if k < 0 || int(k) >= len(stepKindNames) {
+		return fmt.Sprintf("StepKind(%d)", k)
+	}
+	return stepKindNames[k]
+}
+
+// MarshalText allows the step kind to be printed by name in the audit output
+func (k StepKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// UnmarshalText allows the step kind to be read by name from the audit output
+func (k *StepKind) UnmarshalText(text []byte) error {
+	*k = ParseStepKeyword(string(text))
+	return nil
+}
+
+// ParseStepKeyword converts a gherkin keyword such as "Given " into a StepKind.
+// Conjunctions such as "And", "But" and "*" return UnknownStep, as their kind depends on the previous step.
+func ParseStepKeyword(keyword string) StepKind {
+	switch strings.ToLower(strings.TrimSpace(keyword)) {
+	case "given":
+		return GivenStep
+	case "when":
+		return WhenStep
+	case "then":
+		return ThenStep
+	}
+	return UnknownStep
+}
+
+// SetStepKeyword records the gherkin keyword for the next step to be audited.
+// This is intended to be called from a godog BeforeStep hook.
+func (p *Scenario) SetStepKeyword(keyword string) {
+	p.nextKind = ParseStepKeyword(keyword)
+	if p.nextKind == UnknownStep {
+		p.nextKind = p.previousKind()
+	}
+}
+
+// AuditScenarioGiven audits a precondition step. If any given step fails, the scenario result is "Given Not Met".
+// This function should be deferred to catch panic behavior, otherwise the audit will not be logged on panic
+func (p *Scenario) AuditScenarioGiven(stepName, description string, payload interface{}, err error) {
+	p.nextKind = GivenStep
+	p.audit(stepCallerName(), stepName, description, payload, err)
+}
+
+// AuditScenarioWhen audits an action step.
+// This function should be deferred to catch panic behavior, otherwise the audit will not be logged on panic
+func (p *Scenario) AuditScenarioWhen(stepName, description string, payload interface{}, err error) {
+	p.nextKind = WhenStep
+	p.audit(stepCallerName(), stepName, description, payload, err)
+}
+
+// AuditScenarioThen audits an outcome step.
+// This function should be deferred to catch panic behavior, otherwise the audit will not be logged on panic
+func (p *Scenario) AuditScenarioThen(stepName, description string, payload interface{}, err error) {
+	p.nextKind = ThenStep
+	p.audit(stepCallerName(), stepName, description, payload, err)
+}
+
+// stepKind consumes the kind recorded for the next step. If no kind was recorded, the first step is treated
+// as a given, as it was before step kinds were recorded, and later steps are unknown so that their failures
+// fail the scenario
+func (p *Scenario) stepKind() (kind StepKind) {
+	kind, p.nextKind = p.nextKind, UnknownStep
+	if kind == UnknownStep && len(p.Steps) == 0 {
+		return GivenStep
+	}
+	return
+}
+
+func (p *Scenario) previousKind() StepKind {
+	if prev, ok := p.Steps[len(p.Steps)]; ok {
+		return prev.Kind
+	}
+	return UnknownStep
+}
+
+// computeResult evaluates all audited steps; any failed given means the scenario's preconditions were not met.
+// Undefined steps fail the scenario in the same way.
+func (p *Scenario) computeResult() string {
+	result := "Passed"
+	for _, s := range p.Steps {
+		if s.Result != "Failed" && s.Result != "Undefined" {
+			continue
+		}
+		if s.Kind == GivenStep {
+			return "Given Not Met"
+		}
+		result = "Failed"
+	}
+	return result
+}
+
+// BeginStep starts auditing a step automatically, such as from a godog BeforeStep hook.
+// Until EndStep is called, AuditScenarioStep only adds detail to this step rather than creating a new one.
+func (p *Scenario) BeginStep(text, keyword string, argument interface{}) {
+	p.SetStepKeyword(keyword)
+	stepNumber := len(p.Steps) + 1
+	p.Steps[stepNumber] = &step{
+		Name:     text,
+		Text:     text,
+		Kind:     p.stepKind(),
+		Argument: argument,
+	}
+	p.activeStep = p.Steps[stepNumber]
+	p.stepStart = time.Now()
+}
+
+// EndStep completes the step started by BeginStep, such as from a godog AfterStep hook
+func (p *Scenario) EndStep(err error) {
+	s := p.activeStep
+	if s == nil {
+		return
+	}
+	p.activeStep = nil
+	s.Duration = time.Since(p.stepStart).String()
+	if err != nil {
+		s.setError(err)
+	}
+	if s.Result == "" {
+		s.Result = "Passed"
+	}
+	if s.Result == "Failed" {
+		s.Remediation = p.stepRemediation(s.Function, s.Name)
+	}
+	p.setResult()
+}
+
+// SkipStep completes a step started by BeginStep that was not run, such as from the next godog BeforeStep hook or
+// an AfterScenario hook, as godog does not call AfterStep for these steps. The step is recorded as "Skipped" if an
+// earlier step failed or was undefined, otherwise it had no step definition and is recorded as "Undefined",
+// which fails the scenario. SkipStep does nothing if no step is in progress.
+func (p *Scenario) SkipStep() {
+	s := p.activeStep
+	if s == nil {
+		return
+	}
+	p.activeStep = nil
+	s.Result = "Undefined"
+	for _, earlier := range p.Steps {
+		if earlier != s && (earlier.Result == "Failed" || earlier.Result == "Undefined" || earlier.Result == "Skipped") {
+			s.Result = "Skipped"
+			break
+		}
+	}
+	p.setResult()
+}
+
+// addDetail merges values provided by a step function into an automatically audited step
+func (s *step) addDetail(functionName, stepName, description string, payload interface{}, err error) {
+	s.Function = functionName
+	if stepName != "" {
+		s.Name = stepName
+	}
+	if description != "" {
+		s.Description = description
+	}
+	if payload != nil {
+		s.Payload = payload
+	}
+	if err != nil {
+		s.setError(err)
+	}
+}
+
+func (s *step) setError(err error) {
+	s.Result = "Failed"
+	s.Error = strings.Replace(err.Error(), "[ERROR] ", "", -1)
+}
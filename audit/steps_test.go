@@ -0,0 +1,131 @@
+package audit
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestScenario_ComputeResult(t *testing.T) {
+	failure := errors.New("failed")
+	tests := []struct {
+		testName string
+		audit    func(s *Scenario)
+		expected string
+	}{
+		{
+			testName: "All steps passing should pass",
+			audit: func(s *Scenario) {
+				s.AuditScenarioGiven("given", "", nil, nil)
+				s.AuditScenarioThen("then", "", nil, nil)
+			},
+			expected: "Passed",
+		},
+		{
+			testName: "Failed second given should not be treated as a failure",
+			audit: func(s *Scenario) {
+				s.AuditScenarioGiven("first given", "", nil, nil)
+				s.AuditScenarioGiven("second given", "", nil, failure)
+			},
+			expected: "Given Not Met",
+		},
+		{
+			testName: "Failed then should fail the scenario",
+			audit: func(s *Scenario) {
+				s.AuditScenarioGiven("given", "", nil, nil)
+				s.AuditScenarioWhen("when", "", nil, nil)
+				s.AuditScenarioThen("then", "", nil, failure)
+			},
+			expected: "Failed",
+		},
+		{
+			testName: "Conjunction keyword should inherit the previous kind",
+			audit: func(s *Scenario) {
+				s.SetStepKeyword("Given ")
+				s.AuditScenarioStep("given", "", nil, nil)
+				s.SetStepKeyword("And ")
+				s.AuditScenarioStep("and", "", nil, failure)
+			},
+			expected: "Given Not Met",
+		},
+		{
+			testName: "First untyped step should be treated as a given",
+			audit: func(s *Scenario) {
+				s.AuditScenarioStep("first", "", nil, failure)
+			},
+			expected: "Given Not Met",
+		},
+		{
+			testName: "Later untyped steps should fail the scenario",
+			audit: func(s *Scenario) {
+				s.AuditScenarioStep("given", "", nil, nil)
+				s.AuditScenarioStep("when", "", nil, nil)
+				s.AuditScenarioStep("then", "", nil, failure)
+			},
+			expected: "Failed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			s := (&Probe{}).InitializeAuditor(tt.testName, nil)
+			tt.audit(s)
+			if s.Result != tt.expected {
+				t.Errorf("Result = %v, Expected: %v", s.Result, tt.expected)
+			}
+		})
+	}
+}
+
+var testScenario *Scenario
+
+func aDeferredStep() error {
+	defer testScenario.AuditScenarioWhen("deferred", "", nil, nil)
+	return nil
+}
+
+func aDirectStep() error {
+	testScenario.AuditScenarioThen("direct", "", nil, nil)
+	return nil
+}
+
+func TestScenario_UntypedStepKinds(t *testing.T) {
+	s := (&Probe{}).InitializeAuditor("scenario", nil)
+	s.AuditScenarioStep("given", "", nil, nil)
+	s.AuditScenarioStep("when", "", nil, nil)
+	s.AuditScenarioStep("then", "", nil, nil)
+
+	expected := []StepKind{GivenStep, UnknownStep, UnknownStep}
+	for i, kind := range expected {
+		if s.Steps[i+1].Kind != kind {
+			t.Errorf("Step %d Kind = %v, Expected: %v", i+1, s.Steps[i+1].Kind, kind)
+		}
+	}
+}
+
+func TestScenario_AuditStepFunctionName(t *testing.T) {
+	testScenario = (&Probe{}).InitializeAuditor("scenario", nil)
+	// godog invokes step functions via reflection, so the same is done here
+	reflect.ValueOf(aDeferredStep).Call(nil)
+	reflect.ValueOf(aDirectStep).Call(nil)
+
+	if testScenario.Steps[1].Function != "aDeferredStep" {
+		t.Errorf("Deferred audit Function = %v, Expected: aDeferredStep", testScenario.Steps[1].Function)
+	}
+	if testScenario.Steps[2].Function != "aDirectStep" {
+		t.Errorf("Direct audit Function = %v, Expected: aDirectStep", testScenario.Steps[2].Function)
+	}
+}
+
+func TestStepKind_String(t *testing.T) {
+	tests := map[StepKind]string{
+		UnknownStep:  "Unknown",
+		ThenStep:     "Then",
+		StepKind(-1): "StepKind(-1)",
+		StepKind(4):  "StepKind(4)",
+	}
+	for k, expected := range tests {
+		if k.String() != expected {
+			t.Errorf("String() = %s, Expected: %s", k.String(), expected)
+		}
+	}
+}
@@ -3,9 +3,10 @@ package audit
 import (
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"path/filepath"
+	"sync"
+	"time"
 
 	"github.com/probr/probr-sdk/config"
 	"github.com/probr/probr-sdk/utils"
@@ -18,8 +19,15 @@ type SummaryState struct {
 	ProbesPassed   int
 	ProbesFailed   int
 	ProbesSkipped  int
+	RiskScore      int
 	Probes         map[string]*Probe
 	WriteDirectory string
+	sinks          []Sink
+	sinksOnce      sync.Once
+	notifiers      []*Notifier
+	notifiersOnce  sync.Once
+	opts           *config.GlobalOpts
+	mux            sync.Mutex // Guards Probes and the probe counts, which may be read while probes are running
 }
 
 // SummaryState is a stateful object intended to hold all the high-level info about a probe execution
@@ -29,6 +37,7 @@ type limitedSummaryState struct {
 	ProbesPassed   int
 	ProbesFailed   int
 	ProbesSkipped  int
+	RiskScore      int
 	Probes         map[string]*limitedProbe
 	WriteDirectory string
 }
@@ -43,19 +52,64 @@ func NewSummaryState(packName string, defaultLogger ...bool) (state SummaryState
 	return
 }
 
+// SetConfig sets the config used for output locations, sinks and notifications.
+// If it is not set, config.GlobalConfig is used.
+func (s *SummaryState) SetConfig(c *config.GlobalOpts) {
+	s.opts = c
+}
+
+func (s *SummaryState) config() *config.GlobalOpts {
+	if s.opts == nil {
+		return &config.GlobalConfig
+	}
+	return s.opts
+}
+
 // PrintSummary will print the current object state, formatted to JSON
 func (s *SummaryState) PrintSummary() {
 	log.Printf("Summary: %s", s.summary()) // Summary output should not be handled by log levels
 }
 
-// WriteSummary will write the summary to the audit directory
+// AddSink adds a destination for the summary and probe audits.
+// If no sinks are added, those configured in the config's Sinks are used,
+// otherwise output is written to the configured WriteDirectory. Sinks must be added before any output is written.
+func (s *SummaryState) AddSink(sink Sink) {
+	s.sinks = append(s.sinks, sink)
+}
+
+// WriteSummary will write the summary to each sink
 func (s *SummaryState) WriteSummary() {
-	path := filepath.Join(config.GlobalConfig.WriteDirectory, "summary.json")
-	if utils.WriteAllowed(path) {
-		ioutil.WriteFile(path, s.summary(), 0755)
+	summary := s.summary()
+	for _, sink := range s.getSinks() {
+		if err := sink.WriteSummary(summary); err != nil {
+			log.Printf("[ERROR] Failed to write summary to %T: %v", sink, err)
+		}
 	}
 }
 
+// getSinks resolves the sinks from config once, the first time output is written, after config has been initialized.
+// File sinks without a directory write to the summary's WriteDirectory.
+func (s *SummaryState) getSinks() []Sink {
+	s.sinksOnce.Do(func() {
+		if s.sinks == nil {
+			sinks, err := NewSinks(s.config().Sinks)
+			if err != nil {
+				log.Printf("[ERROR] %v", err)
+			}
+			if len(sinks) == 0 {
+				sinks = []Sink{&FileSink{}}
+			}
+			s.sinks = sinks
+		}
+		for _, sink := range s.sinks {
+			if f, ok := sink.(*FileSink); ok && f.Directory == "" {
+				f.Directory = s.config().WriteDirectory
+			}
+		}
+	})
+	return s.sinks
+}
+
 // summary will marshal obj as json, unmarshal into limited obj, then marshal again & write/print
 func (s *SummaryState) summary() []byte {
 	var limitedObj limitedSummaryState
@@ -67,30 +121,97 @@ func (s *SummaryState) summary() []byte {
 	return utils.JSON(limitedObj)
 }
 
-// SetProbrStatus evaluates the current SummaryState state to set the Status
+// SetProbrStatus evaluates the current SummaryState state to set the Status, then sends any notifications
 func (s *SummaryState) SetProbrStatus() {
+	s.mux.Lock()
 	attempted := (len(s.Probes) - s.ProbesSkipped)
 	succeeded := (attempted - s.ProbesFailed)
 	s.Status = fmt.Sprintf("Complete - %d/%d Succeeded (%d Skipped)", succeeded, attempted, s.ProbesSkipped)
+	s.mux.Unlock()
+	s.notify()
+}
+
+// ExitCode returns 1 if any probe has a failed scenario with a severity at or above the provided minimum, otherwise 0
+func (s *SummaryState) ExitCode(minimum Severity) int {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	for _, probe := range s.Probes {
+		if probe.ScenariosFailed > 0 && probe.HighestFailedSeverity() >= minimum {
+			return 1
+		}
+	}
+	return 0
+}
+
+// Snapshot returns a copy of the summary holding only the probes that have completed, which is safe to read
+// while other probes are still running
+func (s *SummaryState) Snapshot() *SummaryState {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	snapshot := &SummaryState{
+		Meta:           s.Meta,
+		Status:         s.Status,
+		ProbesPassed:   s.ProbesPassed,
+		ProbesFailed:   s.ProbesFailed,
+		ProbesSkipped:  s.ProbesSkipped,
+		RiskScore:      s.RiskScore,
+		Probes:         make(map[string]*Probe),
+		WriteDirectory: s.WriteDirectory,
+		opts:           s.opts,
+	}
+	for name, p := range s.Probes {
+		if p.EndTime.IsZero() {
+			continue
+		}
+		probe := *p
+		probe.Meta = make(map[string]interface{}, len(p.Meta))
+		for key, value := range p.Meta {
+			probe.Meta[key] = value
+		}
+		snapshot.Probes[name] = &probe
+	}
+	return snapshot
 }
 
 // LogProbeMeta accepts a test name with a key and value to insert to the meta logs for that test. Overwrites key if already present.
 func (s *SummaryState) LogProbeMeta(name string, key string, value interface{}) {
-	probe := s.GetProbeLog(name)
-	probe.Meta[key] = value
-	s.Probes[name] = probe
-	s.Probes[name].name = name // probe must be able to access its own name, but it is not publicly printed
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.probeLog(name).Meta[key] = value
+}
+
+// ProbeStarted records the time at which the named probe began executing
+func (s *SummaryState) ProbeStarted(name string) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.probeLog(name).StartTime = time.Now()
 }
 
 // ProbeComplete takes an probe name and status then updates the summary & probe meta information
 func (s *SummaryState) ProbeComplete(name string) {
-	p := s.GetProbeLog(name)
+	s.mux.Lock()
+	p := s.probeLog(name)
 	s.completeProbe(p)
-	p.Write()
+	s.mux.Unlock()
+	if len(p.Scenarios) == 0 {
+		return
+	}
+	data := utils.JSON(p)
+	for _, sink := range s.getSinks() {
+		if err := sink.WriteProbe(name, data); err != nil {
+			log.Printf("[ERROR] Failed to write audit for probe '%s' to %T: %v", name, sink, err)
+		}
+	}
 }
 
 // GetProbeLog initializes or returns existing log probe for the provided test name
 func (s *SummaryState) GetProbeLog(name string) *Probe {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return s.probeLog(name)
+}
+
+func (s *SummaryState) probeLog(name string) *Probe {
 	// If SummaryState is improperly initialized, a dereference error will occur below.
 	// log.Printf("[DEBUG] GetProbeLog(%s) called by: %s->%s->%s", name, utils.CallerName(1), utils.CallerName(2), utils.CallerName(3))
 	if s.Probes[name] == nil {
@@ -111,12 +232,14 @@ func (s *SummaryState) initProbe(n string) {
 	s.Probes[n] = &Probe{
 		name: n,
 		Meta: make(map[string]interface{}),
-		Path: filepath.Join(config.GlobalConfig.WriteDirectory, "audit", (n + ".json")),
+		Path: filepath.Join(s.config().WriteDirectory, "audit", (n + ".json")),
 	}
 }
 
 func (s *SummaryState) completeProbe(e *Probe) {
+	e.EndTime = time.Now()
 	e.countResults()
+	s.RiskScore = s.RiskScore + e.RiskScore
 	if e.Result == "Excluded" {
 		e.Meta["audit_path"] = ""
 		s.ProbesSkipped = s.ProbesSkipped + 1
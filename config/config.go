@@ -1,7 +1,7 @@
 package config
 
 import (
-	"encoding/json"
+	"fmt"
 	"log"
 	"os"
 	"path/filepath"
@@ -9,6 +9,8 @@ import (
 	"time"
 
 	"github.com/probr/probr-sdk/config/setter"
+	"github.com/probr/probr-sdk/config/tags"
+	"github.com/probr/probr-sdk/config/validator"
 	"github.com/probr/probr-sdk/utils"
 )
 
@@ -16,86 +18,130 @@ import (
 var GlobalConfig GlobalOpts
 
 func init() {
-	GlobalConfig.Init() // Initialize with default values only
+	GlobalConfig.setDefaults() // Initialize with default values only; the rest waits for an explicit Init
 }
 
-// Init ...
-func (ctx *GlobalOpts) Init() {
+// setDefaults applies env vars and defaults without reading vars files, resolving secret references,
+// validating or writing the config, so that importing the package has no side effects
+func (ctx *GlobalOpts) setDefaults() {
 	ctx.StartTime = time.Now()
-	if ctx.VarsFile != "" {
-		ctx.decode()
+	ctx.provenance = make(map[string]string)
+	if err := ctx.setEnvAndDefaults(); err != nil {
+		log.Printf("[ERROR] %v", err)
 	}
-	ctx.setEnvAndDefaults()
+	ctx.recordDefaults()
 }
 
-// decode uses an SDK helper to create a YAML file decoder,
-// parse the file to an object, then extracts the values from
-// ServicePacks.Kubernetes into this context
-func (ctx *GlobalOpts) decode() (err error) {
-	configDecoder, file, err := NewConfigDecoder(ctx.VarsFile)
-	if err != nil {
-		return
+// Init applies each config layer in order of precedence: defaults < vars files < profile < env vars < flags,
+// then resolves any secret references and validates the result. Every problem found is logged and returned together as validator.Errors.
+func (ctx *GlobalOpts) Init() error {
+	ctx.StartTime = time.Now()
+	var problems validator.Errors
+	problems = appendProblems(problems, "layers", ctx.applyLayers())
+	problems = appendProblems(problems, "defaults", ctx.setEnvAndDefaults())
+	problems = appendProblems(problems, "sections", ctx.setSectionEnvAndDefaults())
+	problems = appendProblems(problems, "secrets", ctx.resolveSecrets())
+	ctx.recordDefaults()
+	if err := ctx.Validate(); err != nil {
+		problems = append(problems, err.(validator.Errors)...)
 	}
-	err = configDecoder.Decode(&ctx)
-	file.Close()
-	return
+	if ctx.WriteConfig {
+		_, err := ctx.WriteEffectiveConfig()
+		problems = appendProblems(problems, "WriteConfig", err)
+	}
+	if len(problems) > 0 {
+		log.Printf("[ERROR] %v", problems)
+	}
+	return problems.Err()
 }
 
-// LogConfigState ...
+// LogConfigState prints the config, with secrets redacted, followed by the source of each value
 func (ctx *GlobalOpts) LogConfigState() {
-	json, _ := json.MarshalIndent(ctx, "", "  ")
-	log.Printf("[INFO] Config State: %s", json)
+	state, err := ctx.EffectiveConfig()
+	if err != nil {
+		log.Printf("[ERROR] Unable to render config state: %v", err)
+	}
+	log.Printf("[INFO] Config State:\n%s", state)
+	ctx.logProvenance()
 }
 
 // setEnvOrDefaults will set value from os.Getenv and default to the specified value
-func (ctx *GlobalOpts) setEnvAndDefaults() {
+func (ctx *GlobalOpts) setEnvAndDefaults() (err error) {
+	// Static env vars and defaults are declared via the 'env' and 'default' struct tags in types.go;
+	// env vars have already been applied with a higher precedence than vars files by this point, so only
+	// values without a source are set, keeping those explicitly set to their zero value such as false
+	err = setter.SetUnsetVars(ctx, ctx.isSet)
+
 	// Notes on SetVar's values:
 	// 1. Pointer to local object; will be overwritten by env or default if empty
 	// 2. Name of env var to check
-	// 3. Default value to set if flags, vars file, and env have not provided a value
-
+	// 3. Default value to set if flags, vars files, profile and env have not provided a value
 	home, _ := os.UserHomeDir()
 	setter.SetVar(&ctx.InstallDir, "PROBR_INSTALL_DIR", filepath.Join(home, "probr"))
-
 	setter.SetVar(&ctx.TmpDir, "PROBR_TMP_DIR", filepath.Join(ctx.InstallDir, "tmp"))
 	setter.SetVar(&ctx.WriteDirectory, "PROBR_WRITE_DIRECTORY", ctx.outputDir())
-	setter.SetVar(&ctx.LogLevel, "PROBR_LOG_LEVEL", "DEBUG")
-	setter.SetVar(&ctx.GodogResultsFormat, "PROBR_RESULTS_FORMAT", "cucumber")
+	return
 }
 
-// ParseTags takes two lists of tags and parses them into a cucumber tag string
-// Tags may start with '@' or '~@' respectively, but it is not required
+// JSONLogs reports whether logs should be written as JSON, as set via LogFormat
+func (ctx *GlobalOpts) JSONLogs() bool {
+	return strings.EqualFold(ctx.LogFormat, "json")
+}
+
+// ParseTags takes two lists of tags and converts them into the tag expression syntax expected by godog.
+// Invalid entries are logged, and reported by Validate during Init; see TagExpression for how they are applied.
+// If the combined expression is too complex for godog, it is logged and no scenarios are selected.
 func ParseTags(inclusions, exclusions []string) string {
-	var tags []string
-	if len(inclusions) > 0 {
-		tags = append(tags, parseInclusions(inclusions))
+	e, err := TagExpression(inclusions, exclusions)
+	if err != nil {
+		log.Printf("[ERROR] Ignoring invalid tags. %v", err)
 	}
-	if len(exclusions) > 0 {
-		tags = append(tags, parseExclusions(exclusions))
+	s, err := tags.Godog(e)
+	if err != nil {
+		log.Printf("[ERROR] No scenarios will be selected. %v", err)
+		s, _ = tags.Godog(tags.None())
 	}
-	// If only one is provided, this joiner won't be used
-	return strings.Join(tags, " && ")
-}
-
-func parseInclusions(inclusions []string) string {
-	inclusions = prependTags(inclusions, "@")
-	return strings.Join(inclusions, ",")
+	return s
 }
 
-func parseExclusions(exclusions []string) string {
-	exclusions = prependTags(exclusions, "~@")
-	return strings.Join(exclusions, " && ")
+// TagExpression combines two lists of tags into an expression matching scenarios with any of the inclusions
+// and none of the exclusions. Each entry may be a single tag, with or without '@', or an expression such as
+// "@k-iam and not @wip". A leading '~' on an exclusion is ignored, for compatibility with the previous syntax.
+// Invalid entries are returned as validator.Errors, as is an expression that is too complex to convert for godog.
+// Invalid entries are left out of the expression, except that if inclusions were given but none are valid,
+// the expression matches no scenarios rather than every scenario.
+func TagExpression(inclusions, exclusions []string) (tags.Expr, error) {
+	var problems validator.Errors
+	included, problems := parseTagList("TagInclusions", inclusions, false, problems)
+	invalid := len(problems)
+	excluded, problems := parseTagList("TagExclusions", exclusions, true, problems)
+	if invalid > 0 && len(included) == 0 {
+		return tags.None(), problems.Err()
+	}
+	e := tags.And(tags.Or(included...), tags.Not(tags.Or(excluded...)))
+	if _, err := tags.Godog(e); err != nil {
+		problems = append(problems, validator.FieldError{Field: "TagInclusions", Rule: "tags", Message: err.Error()})
+	}
+	return e, problems.Err()
 }
 
-func prependTags(tags []string, prefix string) []string {
-	for i, value := range tags {
-		// ensure value is not empty, then force it to begin with prefix
-		// prefix value is expected to not be found anywhere but as the prefix
-		if len(value) > 0 && !strings.Contains(value, prefix) {
-			tags[i] = prefix + value
+func parseTagList(key string, list []string, exclusions bool, problems validator.Errors) ([]tags.Expr, validator.Errors) {
+	var parsed []tags.Expr
+	for i, entry := range list {
+		if exclusions {
+			entry = strings.TrimPrefix(strings.TrimSpace(entry), "~")
+		}
+		e, err := tags.Parse(entry)
+		if err, ok := err.(*tags.SyntaxError); ok {
+			message := fmt.Sprintf("'%s' is not a valid tag expression: %s at column %d", err.Expression, err.Message, err.Column)
+			problems = append(problems, validator.FieldError{Field: fmt.Sprintf("%s[%d]", key, i), Rule: "tags", Message: message})
+			continue
+		}
+		if e != nil {
+			parsed = append(parsed, e)
 		}
 	}
-	return tags
+	return parsed, problems
 }
 
 // CleanupTmp is used to dispose of any temp resources used during execution
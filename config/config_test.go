@@ -1,9 +1,12 @@
 package config
 
 import (
+	"fmt"
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/probr/probr-sdk/config/validator"
 )
 
 func TestGlobalOpts_OutputDir(t *testing.T) {
@@ -33,3 +36,98 @@ func TestGlobalOpts_OutputDir(t *testing.T) {
 		})
 	}
 }
+
+func TestGlobalOpts_Validate(t *testing.T) {
+	defer func() {
+		validationsMux.Lock()
+		delete(validations, "test-pack")
+		validationsMux.Unlock()
+	}()
+	RegisterValidation("test-pack", func(ctx *GlobalOpts) error {
+		if ctx.TmpDir == "" {
+			return fmt.Errorf("TmpDir is required by test-pack")
+		}
+		return nil
+	})
+
+	ctx := GlobalOpts{
+		LogLevel:      "LOUD",
+		Notifications: []NotificationOpts{{URL: "not a url"}},
+	}
+	err := ctx.Validate()
+	errs, ok := err.(validator.Errors)
+	if !ok || len(errs) != 3 {
+		t.Fatalf("Expected 3 problems, got: %v", err)
+	}
+	if errs[2].Field != "test-pack" {
+		t.Errorf("Expected the registered validation to be reported last, got: %v", errs[2])
+	}
+}
+
+func TestParseTags(t *testing.T) {
+	tests := []struct {
+		name       string
+		inclusions []string
+		exclusions []string
+		want       string
+	}{
+		{"empty", nil, nil, ""},
+		{"inclusions only", []string{"a", "@b"}, nil, "@a,@b"},
+		{"exclusions only", nil, []string{"~@a", "b"}, "~@a && ~@b"},
+		{"both", []string{"a", "b"}, []string{"c"}, "@a,@b && ~@c"},
+		{"expressions", []string{"@a and not @b"}, []string{"@c or @d"}, "@a && ~@b && ~@c && ~@d"},
+		{"tags containing '@'", []string{"a"}, []string{"probes@v2"}, "@a"},
+		{"some invalid inclusions", []string{"@a and", "@b"}, nil, "@b"},
+		{"all invalid inclusions", []string{"@a and", "or @b"}, []string{"c"}, "@probr-none && ~@probr-none"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseTags(tt.inclusions, tt.exclusions); got != tt.want {
+				t.Errorf("ParseTags() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGlobalOpts_Validate_Tags(t *testing.T) {
+	ctx := GlobalOpts{TagInclusions: []string{"@a", "@b and"}}
+	errs, ok := ctx.Validate().(validator.Errors)
+	if !ok || len(errs) != 1 || errs[0].Field != "TagInclusions[1]" {
+		t.Errorf("Expected the invalid tag expression to be reported, got: %v", errs)
+	}
+}
+
+func TestParseTags_TooComplex(t *testing.T) {
+	var inclusions []string
+	for i := 0; i < 11; i++ {
+		inclusions = append(inclusions, fmt.Sprintf("@a%d and @b%d", i, i))
+	}
+	if got := ParseTags(inclusions, nil); got != "@probr-none && ~@probr-none" {
+		t.Errorf("Expected no scenarios to be selected for a complex expression, got %q", got)
+	}
+}
+
+func TestGlobalOpts_Validate_ComplexTags(t *testing.T) {
+	// Each inclusion is valid, but together they expand to more groups than godog's syntax allows
+	var inclusions []string
+	for i := 0; i < 11; i++ {
+		inclusions = append(inclusions, fmt.Sprintf("@a%d and @b%d", i, i))
+	}
+	ctx := GlobalOpts{TagInclusions: inclusions}
+	errs, ok := ctx.Validate().(validator.Errors)
+	if !ok || len(errs) != 1 || errs[0].Field != "TagInclusions" || !strings.Contains(errs[0].Message, "too complex") {
+		t.Errorf("Expected the combined tag expression to be reported, got: %v", errs)
+	}
+}
+
+func TestGlobalOpts_Validate_ExitSeverity(t *testing.T) {
+	ctx := GlobalOpts{ExitSeverity: "severe"}
+	errs, ok := ctx.Validate().(validator.Errors)
+	if !ok || len(errs) != 1 || errs[0].Field != "ExitSeverity" {
+		t.Errorf("Expected the invalid exit severity to be reported, got: %v", errs)
+	}
+	ctx.ExitSeverity = "High"
+	if err := ctx.Validate(); err != nil {
+		t.Errorf("Expected severities to be case-insensitive, got: %v", err)
+	}
+}
@@ -0,0 +1,405 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/probr/probr-sdk/config/setter"
+)
+
+// Configuration is layered with the following precedence, lowest first:
+// defaults < overrides set on the struct before Init < vars files (merged in order) < named profile < env vars < flags
+
+// Source values used when recording where each config value came from
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceProfile = "profile"
+	SourceEnv     = "env"
+	SourceFlag    = "flag"
+	SourceCode    = "code"
+)
+
+// profileEnvVar may be used to select a named profile from the vars files
+const profileEnvVar = "PROBR_PROFILE"
+
+// Provenance returns the source of each config value that has been set, keyed by its dotted YAML path
+func (ctx *GlobalOpts) Provenance() map[string]string {
+	p := make(map[string]string)
+	for k, v := range ctx.provenance {
+		p[k] = v
+	}
+	return p
+}
+
+// SetFlag records a flag value to be applied with the highest precedence when Init is run.
+// Key is the dotted YAML path to the config value, such as "CloudProviders.Azure.TenantID".
+func (ctx *GlobalOpts) SetFlag(key, value string) error {
+	if _, err := ctx.fieldByKey(key); err != nil {
+		return err
+	}
+	if ctx.flags == nil {
+		ctx.flags = make(map[string]string)
+	}
+	ctx.flags[key] = value
+	return nil
+}
+
+// BindFlags registers a flag on fs for each config key, as well as 'varsfile' (repeatable) and 'profile'.
+// Only flags that are explicitly set on the command line will override other config layers.
+func (ctx *GlobalOpts) BindFlags(fs *flag.FlagSet) {
+	fs.Var((*varsFileFlag)(ctx), "varsfile", "Path to a vars file. May be repeated; later files override earlier ones")
+	fs.StringVar(&ctx.Profile, "profile", "", fmt.Sprintf("Name of a profile from the vars files to apply (env: %s)", profileEnvVar))
+	for _, key := range ctx.keys() {
+		usage := fmt.Sprintf("Overrides the config value %s", key)
+		if env, ok := ctx.envVars()[key]; ok {
+			usage = fmt.Sprintf("%s (env: %s)", usage, env)
+		}
+		fs.Var(&keyFlag{ctx: ctx, key: key}, key, usage)
+	}
+}
+
+// keyFlag records a value via SetFlag for a single config key
+type keyFlag struct {
+	ctx   *GlobalOpts
+	key   string
+	value string
+}
+
+func (k *keyFlag) String() string {
+	if k == nil {
+		return ""
+	}
+	return k.value
+}
+
+func (k *keyFlag) Set(value string) error {
+	k.value = value
+	return k.ctx.SetFlag(k.key, value)
+}
+
+// varsFileFlag allows the varsfile flag to be repeated; the first value is used as VarsFile
+type varsFileFlag GlobalOpts
+
+func (v *varsFileFlag) String() string {
+	if v == nil {
+		return ""
+	}
+	return strings.Join(append([]string{v.VarsFile}, v.VarsFiles...), ",")
+}
+
+func (v *varsFileFlag) Set(path string) error {
+	if v.VarsFile == "" {
+		v.VarsFile = path
+	} else {
+		v.VarsFiles = append(v.VarsFiles, path)
+	}
+	return nil
+}
+
+// applyLayers decodes each vars file, the selected profile, env vars and flags in order of precedence
+func (ctx *GlobalOpts) applyLayers() (err error) {
+	ctx.recordOverrides()
+	ctx.provenance = make(map[string]string)
+	var overrides []string
+	for key := range ctx.overrides {
+		overrides = append(overrides, key)
+	}
+	sort.Strings(overrides)
+	for _, key := range overrides {
+		field, err := ctx.fieldByKey(key)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(ctx.overrides[key]))
+		ctx.provenance[key] = SourceCode
+	}
+	profiles := make(map[string]map[interface{}]interface{})
+
+	for _, path := range ctx.varsFiles() {
+		layer, err := readLayer(path)
+		if err != nil {
+			return err
+		}
+		if p, ok := layer["Profiles"].(map[interface{}]interface{}); ok {
+			for name, values := range p {
+				if values, ok := values.(map[interface{}]interface{}); ok {
+					profiles[fmt.Sprint(name)] = mergeMaps(profiles[fmt.Sprint(name)], values)
+				}
+			}
+			delete(layer, "Profiles")
+		}
+		if err = ctx.applyLayer(layer, SourceFile+":"+path); err != nil {
+			return err
+		}
+	}
+
+	if ctx.Profile == "" {
+		ctx.Profile = os.Getenv(profileEnvVar)
+	}
+	if ctx.Profile != "" {
+		profile, ok := profiles[ctx.Profile]
+		if !ok {
+			return fmt.Errorf("profile '%s' was not found in the provided vars files", ctx.Profile)
+		}
+		if err = ctx.applyLayer(profile, SourceProfile+":"+ctx.Profile); err != nil {
+			return err
+		}
+	}
+
+	envVars := ctx.envVars()
+	for _, key := range sortedKeys(envVars) {
+		if value := os.Getenv(envVars[key]); value != "" {
+			if err = ctx.setKey(key, value, SourceEnv+":"+envVars[key]); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, key := range sortedKeys(ctx.flags) {
+		if err = ctx.setKey(key, ctx.flags[key], SourceFlag+":"+key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordOverrides keeps each value that was set on the struct before Init, so that it can be applied again when
+// the config is reloaded. Values that already have a source were set by a previous Init rather than by the caller.
+func (ctx *GlobalOpts) recordOverrides() {
+	if ctx.overrides == nil {
+		ctx.overrides = make(map[string]interface{})
+	}
+	for _, key := range ctx.keys() {
+		if _, ok := ctx.provenance[key]; ok {
+			continue
+		}
+		if field, err := ctx.fieldByKey(key); err == nil && !field.IsZero() {
+			ctx.overrides[key] = field.Interface()
+		}
+	}
+}
+
+// isSet reports whether the value at key was provided by the caller, a vars file, profile, env var or flag
+func (ctx *GlobalOpts) isSet(key string) bool {
+	_, ok := ctx.provenance[key]
+	return ok
+}
+
+// recordDefaults marks any populated value without a recorded source as a default
+func (ctx *GlobalOpts) recordDefaults() {
+	for _, key := range ctx.keys() {
+		if _, ok := ctx.provenance[key]; ok {
+			continue
+		}
+		if field, err := ctx.fieldByKey(key); err == nil && !field.IsZero() {
+			ctx.provenance[key] = SourceDefault
+		}
+	}
+}
+
+func (ctx *GlobalOpts) varsFiles() (files []string) {
+	if ctx.VarsFile != "" {
+		files = append(files, ctx.VarsFile)
+	}
+	return append(files, ctx.VarsFiles...)
+}
+
+func readLayer(path string) (layer map[interface{}]interface{}, err error) {
+	decoder, file, err := NewConfigDecoder(path)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	err = decoder.Decode(&layer)
+	if err != nil {
+		err = fmt.Errorf("failed to decode vars file '%s': %v", path, err)
+	}
+	return
+}
+
+// applyLayer decodes a generic YAML map onto the config and records the source of each key it contains
+func (ctx *GlobalOpts) applyLayer(layer map[interface{}]interface{}, source string) error {
+	data, err := yaml.Marshal(layer)
+	if err != nil {
+		return err
+	}
+	if err = yaml.Unmarshal(data, ctx); err != nil {
+		return fmt.Errorf("failed to apply config from %s: %v", source, err)
+	}
+	if err = ctx.applySectionLayers(layer, source); err != nil {
+		return err
+	}
+	for _, key := range flattenKeys("", layer) {
+		ctx.provenance[key] = source
+	}
+	return nil
+}
+
+// setKey parses value according to the type of the config field at key
+func (ctx *GlobalOpts) setKey(key, value, source string) error {
+	field, err := ctx.fieldByKey(key)
+	if err != nil {
+		return err
+	}
+	if err = setter.SetString(field.Addr().Interface(), value); err != nil {
+		return fmt.Errorf("invalid value '%s' for %s from %s: %v", value, key, source, err)
+	}
+	ctx.provenance[key] = source
+	return nil
+}
+
+// fieldByKey finds the settable field for a dotted YAML path such as "CloudProviders.Azure.TenantID",
+// including those within registered sections
+func (ctx *GlobalOpts) fieldByKey(key string) (reflect.Value, error) {
+	v := reflect.ValueOf(ctx).Elem()
+	path := key
+	if section, relative := ctx.sectionForKey(key); section != nil {
+		v = reflect.ValueOf(section).Elem()
+		path = relative
+	}
+	for _, name := range strings.Split(path, ".") {
+		if v.Kind() != reflect.Struct {
+			return reflect.Value{}, fmt.Errorf("'%s' is not a configurable value", key)
+		}
+		next := reflect.Value{}
+		for i := 0; i < v.NumField(); i++ {
+			if yamlName(v.Type().Field(i)) == name {
+				next = v.Field(i)
+				break
+			}
+		}
+		if !next.IsValid() {
+			return reflect.Value{}, fmt.Errorf("'%s' is not a configurable value", key)
+		}
+		v = next
+	}
+	if v.Kind() == reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("'%s' is a section rather than a configurable value", key)
+	}
+	return v, nil
+}
+
+// envVars maps the dotted YAML path of each value to the env var declared in its 'env' struct tag
+func (ctx *GlobalOpts) envVars() map[string]string {
+	envVars := make(map[string]string)
+	collectEnvVars("", reflect.TypeOf(*ctx), envVars)
+	for key, section := range ctx.sections {
+		collectEnvVars(key+".", reflect.TypeOf(section).Elem(), envVars)
+	}
+	return envVars
+}
+
+func collectEnvVars(prefix string, t reflect.Type, envVars map[string]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := yamlName(f)
+		if name == "" || name == "-" {
+			continue
+		}
+		if env := f.Tag.Get("env"); env != "" {
+			envVars[prefix+name] = env
+		} else if f.Type.Kind() == reflect.Struct {
+			collectEnvVars(prefix+name+".", f.Type, envVars)
+		}
+	}
+}
+
+// keys lists the dotted YAML path of every value that may be set via env vars or flags
+func (ctx *GlobalOpts) keys() []string {
+	keys := leafKeys("", reflect.TypeOf(*ctx))
+	for _, key := range ctx.sectionKeys() {
+		keys = append(keys, leafKeys(key+".", reflect.TypeOf(ctx.sections[key]).Elem())...)
+	}
+	return keys
+}
+
+func leafKeys(prefix string, t reflect.Type) (keys []string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := yamlName(f)
+		if name == "" || name == "-" {
+			continue
+		}
+		switch f.Type.Kind() {
+		case reflect.Struct:
+			keys = append(keys, leafKeys(prefix+name+".", f.Type)...)
+		case reflect.String, reflect.Bool, reflect.Int, reflect.Int64:
+			keys = append(keys, prefix+name)
+		case reflect.Slice, reflect.Map:
+			if f.Type.Elem().Kind() == reflect.String {
+				keys = append(keys, prefix+name)
+			}
+		}
+	}
+	return
+}
+
+func yamlName(f reflect.StructField) string {
+	return strings.Split(f.Tag.Get("yaml"), ",")[0]
+}
+
+// flattenKeys lists the dotted path of each leaf in a generic YAML map
+func flattenKeys(prefix string, m map[interface{}]interface{}) (keys []string) {
+	for k, v := range m {
+		key := prefix + fmt.Sprint(k)
+		if nested, ok := v.(map[interface{}]interface{}); ok {
+			keys = append(keys, flattenKeys(key+".", nested)...)
+		} else {
+			keys = append(keys, key)
+		}
+	}
+	return
+}
+
+// mergeMaps deeply merges overlay onto base, with overlay taking precedence
+func mergeMaps(base, overlay map[interface{}]interface{}) map[interface{}]interface{} {
+	if base == nil {
+		base = make(map[interface{}]interface{})
+	}
+	for k, v := range overlay {
+		baseChild, baseIsMap := base[k].(map[interface{}]interface{})
+		overlayChild, overlayIsMap := v.(map[interface{}]interface{})
+		if baseIsMap && overlayIsMap {
+			base[k] = mergeMaps(baseChild, overlayChild)
+		} else {
+			base[k] = v
+		}
+	}
+	return base
+}
+
+func sortedKeys(m map[string]string) (keys []string) {
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return
+}
+
+// logProvenance prints where each config value came from, one line per source to keep the output readable
+func (ctx *GlobalOpts) logProvenance() {
+	bySource := make(map[string][]string)
+	for key, source := range ctx.provenance {
+		bySource[source] = append(bySource[source], key)
+	}
+	for _, source := range sortedSources(bySource) {
+		sort.Strings(bySource[source])
+		log.Printf("[INFO] Config from %s: %s", source, strings.Join(bySource[source], ", "))
+	}
+}
+
+func sortedSources(m map[string][]string) (sources []string) {
+	for k := range m {
+		sources = append(sources, k)
+	}
+	sort.Strings(sources)
+	return
+}
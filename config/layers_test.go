@@ -0,0 +1,143 @@
+package config
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeVarsFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestGlobalOpts_Layers(t *testing.T) {
+	base := writeVarsFile(t, "base.yml", `
+LogLevel: WARN
+GodogResultsFormat: pretty
+TagInclusions: [one]
+CloudProviders:
+  Azure:
+    TenantID: base-tenant
+    ClientID: base-client
+Profiles:
+  prod:
+    CloudProviders:
+      Azure:
+        ClientID: prod-client
+`)
+	override := writeVarsFile(t, "override.yml", `
+GodogResultsFormat: junit
+CloudProviders:
+  Azure:
+    SubscriptionID: override-subscription
+`)
+	originalValue := os.Getenv("PROBR_AZURE_TENANT_ID")
+	defer os.Setenv("PROBR_AZURE_TENANT_ID", originalValue)
+	os.Setenv("PROBR_AZURE_TENANT_ID", "env-tenant")
+
+	ctx := GlobalOpts{}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	ctx.BindFlags(fs)
+	err := fs.Parse([]string{"-varsfile", base, "-varsfile", override, "-profile", "prod", "-LogLevel", "ERROR"})
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	ctx.Init()
+
+	provenance := ctx.Provenance()
+	tests := []struct {
+		key, got, expected, source string
+	}{
+		{"LogLevel", ctx.LogLevel, "ERROR", "flag:LogLevel"},
+		{"GodogResultsFormat", ctx.GodogResultsFormat, "junit", "file:" + override},
+		{"CloudProviders.Azure.TenantID", ctx.CloudProviders.Azure.TenantID, "env-tenant", "env:PROBR_AZURE_TENANT_ID"},
+		{"CloudProviders.Azure.ClientID", ctx.CloudProviders.Azure.ClientID, "prod-client", "profile:prod"},
+		{"CloudProviders.Azure.SubscriptionID", ctx.CloudProviders.Azure.SubscriptionID, "override-subscription", "file:" + override},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.expected {
+			t.Errorf("%s = %s, Expected: %s", tt.key, tt.got, tt.expected)
+		}
+		if provenance[tt.key] != tt.source {
+			t.Errorf("Provenance of %s = %s, Expected: %s", tt.key, provenance[tt.key], tt.source)
+		}
+	}
+	if !reflect.DeepEqual(ctx.TagInclusions, []string{"one"}) || provenance["TagInclusions"] != "file:"+base {
+		t.Errorf("TagInclusions = %v from %s, Expected: [one] from the base file", ctx.TagInclusions, provenance["TagInclusions"])
+	}
+	if provenance["InstallDir"] != SourceDefault {
+		t.Errorf("Provenance of InstallDir = %s, Expected: %s", provenance["InstallDir"], SourceDefault)
+	}
+}
+
+func TestGlobalOpts_SetFlag(t *testing.T) {
+	ctx := GlobalOpts{}
+	if err := ctx.SetFlag("CloudProviders.Azure", "value"); err == nil {
+		t.Errorf("Expected an error when setting a config section")
+	}
+	if err := ctx.SetFlag("NotAKey", "value"); err == nil {
+		t.Errorf("Expected an error when setting an unknown key")
+	}
+	if err := ctx.SetFlag("TagExclusions", "a,b"); err != nil {
+		t.Errorf("SetFlag() error = %v", err)
+	}
+	ctx.Init()
+	if !reflect.DeepEqual(ctx.TagExclusions, []string{"a", "b"}) {
+		t.Errorf("TagExclusions = %v, Expected: [a b]", ctx.TagExclusions)
+	}
+}
+
+func TestGlobalOpts_MissingProfile(t *testing.T) {
+	ctx := GlobalOpts{Profile: "missing"}
+	if err := ctx.applyLayers(); err == nil {
+		t.Errorf("Expected an error for a profile that does not exist")
+	}
+}
+
+func TestGlobalOpts_SecretReferences(t *testing.T) {
+	secretFile := writeVarsFile(t, "client-secret", "file-secret\n")
+	varsFile := writeVarsFile(t, "vars.yml", `
+CloudProviders:
+  Azure:
+    ClientSecret: file://`+secretFile+`
+Notifications:
+  - URL: https://example.com/hook
+    Secret: env:PROBR_TEST_WEBHOOK_SECRET
+    Template: env:PROBR_TEST_WEBHOOK_SECRET
+    Headers:
+      Authorization: env:PROBR_TEST_WEBHOOK_SECRET
+`)
+	os.Setenv("PROBR_TEST_WEBHOOK_SECRET", "env-secret")
+	defer os.Unsetenv("PROBR_TEST_WEBHOOK_SECRET")
+
+	ctx := GlobalOpts{VarsFile: varsFile}
+	if err := ctx.Init(); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	if ctx.CloudProviders.Azure.ClientSecret != "file-secret" {
+		t.Errorf("ClientSecret = %s, expected file-secret", ctx.CloudProviders.Azure.ClientSecret)
+	}
+	n := ctx.Notifications[0]
+	if n.Secret != "env-secret" || n.Headers["Authorization"] != "env-secret" {
+		t.Errorf("Notification secrets were not resolved: %+v", n)
+	}
+	if !ctx.secrets["Notifications[0].Headers.Authorization"] || !ctx.secrets["CloudProviders.Azure.ClientSecret"] {
+		t.Errorf("Resolved secrets were not recorded: %v", ctx.secrets)
+	}
+	if n.Template != "env:PROBR_TEST_WEBHOOK_SECRET" || ctx.secrets["Notifications[0].Template"] {
+		t.Errorf("Values that are not tagged as secrets should not be resolved: %+v", n)
+	}
+
+	ctx = GlobalOpts{}
+	ctx.CloudProviders.Azure.ClientSecret = "env:PROBR_TEST_UNSET_SECRET"
+	if err := ctx.Init(); err == nil {
+		t.Errorf("Init() expected an error for an unresolvable secret reference")
+	}
+}
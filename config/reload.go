@@ -0,0 +1,212 @@
+package config
+
+import (
+	"log"
+	"os"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/probr/probr-sdk/config/secret"
+)
+
+// DefaultWatchInterval is how often a Watcher checks the vars files for changes
+const DefaultWatchInterval = 5 * time.Second
+
+// Subscriber is notified after a reloaded config has been swapped in, with the dotted keys of each changed value
+type Subscriber func(previous, current *GlobalOpts, changed []string)
+
+// Watcher reloads the config when any vars file changes, for long-running services that run packs on a schedule.
+// Each run should use Acquire to get the config and release it when the run completes. A reloaded config is only
+// swapped in when no runs are active, so a run never sees a partially updated config. Reloads that fail to decode
+// or validate are rejected, and the previous config is kept.
+type Watcher struct {
+	Interval time.Duration // Defaults to DefaultWatchInterval
+
+	mux         sync.Mutex
+	current     *GlobalOpts
+	pending     *GlobalOpts
+	activeRuns  int
+	subscribers []Subscriber
+	modified    map[string]time.Time
+	stop        chan struct{}
+	done        chan struct{}
+}
+
+// NewWatcher creates a Watcher for the vars files, profile, flags, sections and any values set on the struct before Init
+// of an initialized config.
+// Each reload decodes registered sections into new instances, so they should be read via Section on the acquired config.
+func NewWatcher(initial *GlobalOpts) *Watcher {
+	w := &Watcher{current: initial}
+	w.modified = w.modTimes()
+	return w
+}
+
+// Config returns the config currently in use, without acquiring it for a run
+func (w *Watcher) Config() *GlobalOpts {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	return w.current
+}
+
+// Acquire returns the config to be used for a run. The release function must be called when the run is complete,
+// after which any pending reload may be swapped in.
+func (w *Watcher) Acquire() (c *GlobalOpts, release func()) {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	w.activeRuns++
+	var once sync.Once
+	return w.current, func() {
+		once.Do(w.release)
+	}
+}
+
+func (w *Watcher) release() {
+	w.mux.Lock()
+	w.activeRuns--
+	notify := w.swapIfIdle()
+	w.mux.Unlock()
+	notify()
+}
+
+// Subscribe adds a function to be called each time a reloaded config is swapped in
+func (w *Watcher) Subscribe(s Subscriber) {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	w.subscribers = append(w.subscribers, s)
+}
+
+// Start checks the vars files for changes every Interval until Stop is called
+func (w *Watcher) Start() {
+	interval := w.Interval
+	if interval == 0 {
+		interval = DefaultWatchInterval
+	}
+	w.stop = make(chan struct{})
+	w.done = make(chan struct{})
+	go func() {
+		defer close(w.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-w.stop:
+				return
+			case <-ticker.C:
+				if w.changed() {
+					if err := w.Reload(); err != nil {
+						log.Printf("[ERROR] Rejected config reload, keeping the previous config: %v", err)
+					}
+				}
+			}
+		}
+	}()
+}
+
+// Stop ends the polling started by Start
+func (w *Watcher) Stop() {
+	if w.stop == nil {
+		return
+	}
+	close(w.stop)
+	<-w.done
+	w.stop = nil
+}
+
+// Reload re-decodes the vars files and validates the result. If it is valid, it is swapped in as soon as no runs
+// are active; otherwise an error is returned and the current config is kept.
+func (w *Watcher) Reload() error {
+	secret.ClearCache() // Secrets may have been rotated since they were last resolved
+	w.mux.Lock()
+	next, err := w.current.reloadable()
+	w.modified = w.modTimes()
+	w.mux.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if err := next.Init(); err != nil {
+		return err
+	}
+
+	w.mux.Lock()
+	w.pending = next
+	notify := w.swapIfIdle()
+	w.mux.Unlock()
+	notify()
+	return nil
+}
+
+// swapIfIdle replaces the current config with the pending config if no runs are active.
+// It must be called with the lock held, and returns a function that notifies subscribers once the lock is released.
+func (w *Watcher) swapIfIdle() (notify func()) {
+	if w.pending == nil || w.activeRuns > 0 {
+		return func() {}
+	}
+	previous, current := w.current, w.pending
+	w.current, w.pending = current, nil
+	changed := previous.changedKeys(current)
+	log.Printf("[INFO] Reloaded config with %d changed value(s): %v", len(changed), changed)
+
+	subscribers := append([]Subscriber{}, w.subscribers...)
+	return func() {
+		for _, s := range subscribers {
+			s(previous, current, changed)
+		}
+	}
+}
+
+// changed reports whether any vars file has been modified since it was last read
+func (w *Watcher) changed() bool {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	return !reflect.DeepEqual(w.modified, w.modTimes())
+}
+
+func (w *Watcher) modTimes() map[string]time.Time {
+	modified := make(map[string]time.Time)
+	for _, path := range w.current.varsFiles() {
+		if info, err := os.Stat(path); err == nil {
+			modified[path] = info.ModTime()
+		}
+	}
+	return modified
+}
+
+// reloadable creates an uninitialized copy of the config with the same vars files, profile, flags and overrides,
+// and a new instance of each registered section so that the current sections are never modified
+func (ctx *GlobalOpts) reloadable() (*GlobalOpts, error) {
+	next := &GlobalOpts{
+		VarsFile:  ctx.VarsFile,
+		VarsFiles: append([]string{}, ctx.VarsFiles...),
+		Profile:   ctx.Profile,
+		flags:     make(map[string]string),
+		overrides: make(map[string]interface{}),
+	}
+	for k, v := range ctx.flags {
+		next.flags[k] = v
+	}
+	for k, v := range ctx.overrides {
+		next.overrides[k] = v
+	}
+	for key, section := range ctx.sections {
+		if err := next.RegisterSection(key, reflect.New(reflect.TypeOf(section).Elem()).Interface()); err != nil {
+			return nil, err
+		}
+	}
+	return next, nil
+}
+
+// changedKeys lists each key whose value differs between the two configs
+func (ctx *GlobalOpts) changedKeys(other *GlobalOpts) (changed []string) {
+	for _, key := range ctx.keys() {
+		a, aErr := ctx.fieldByKey(key)
+		b, bErr := other.fieldByKey(key)
+		if aErr != nil || bErr != nil || !reflect.DeepEqual(a.Interface(), b.Interface()) {
+			changed = append(changed, key)
+		}
+	}
+	sort.Strings(changed)
+	return
+}
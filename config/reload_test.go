@@ -0,0 +1,141 @@
+package config
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/probr/probr-sdk/config/secret"
+)
+
+func TestWatcher_Reload(t *testing.T) {
+	varsFile := writeVarsFile(t, "vars.yml", "LogLevel: INFO\nGodogResultsFormat: pretty\n")
+	initial := &GlobalOpts{VarsFile: varsFile}
+	section := &testSection{}
+	initial.RegisterSection("ServicePacks.Test", section)
+	if err := initial.Init(); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+
+	w := NewWatcher(initial)
+	var notified []string
+	w.Subscribe(func(previous, current *GlobalOpts, changed []string) {
+		notified = changed
+	})
+
+	// A reload during a run is held until the run is released
+	c, release := w.Acquire()
+	if c != initial {
+		t.Fatalf("Acquire() should return the initial config")
+	}
+	ioutil.WriteFile(varsFile, []byte("LogLevel: ERROR\nGodogResultsFormat: pretty\nServicePacks:\n  Test:\n    Replicas: 4\n"), 0644)
+	if err := w.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if w.Config() != initial || notified != nil {
+		t.Errorf("Config should not be swapped while a run is active")
+	}
+	release()
+	release() // Releasing more than once has no further effect
+
+	current := w.Config()
+	if current == initial || current.LogLevel != "ERROR" || initial.LogLevel != "INFO" {
+		t.Errorf("Expected the reloaded config to be swapped in after the run, without modifying the previous config")
+	}
+	if current.Section("ServicePacks.Test").(*testSection).Replicas != 4 || section.Replicas != 1 {
+		t.Errorf("Expected the reloaded section to be a new instance")
+	}
+	expected := []string{"LogLevel", "ServicePacks.Test.Replicas"}
+	if !reflect.DeepEqual(notified, expected) {
+		t.Errorf("Subscriber notified of %v, expected %v", notified, expected)
+	}
+
+	// Invalid reloads are rejected
+	ioutil.WriteFile(varsFile, []byte("LogLevel: LOUD\n"), 0644)
+	if err := w.Reload(); err == nil {
+		t.Errorf("Reload() expected an error for an invalid config")
+	}
+	if w.Config() != current {
+		t.Errorf("The previous config should be kept after an invalid reload")
+	}
+}
+
+func TestWatcher_Start(t *testing.T) {
+	varsFile := writeVarsFile(t, "vars.yml", "LogLevel: INFO\n")
+	initial := &GlobalOpts{VarsFile: varsFile}
+	initial.Init()
+
+	w := NewWatcher(initial)
+	w.Interval = 10 * time.Millisecond
+	reloaded := make(chan []string, 1)
+	w.Subscribe(func(previous, current *GlobalOpts, changed []string) {
+		reloaded <- changed
+	})
+	w.Start()
+	defer w.Stop()
+
+	ioutil.WriteFile(varsFile, []byte("LogLevel: WARN\n"), 0644)
+	future := time.Now().Add(time.Minute)
+	os.Chtimes(varsFile, future, future) // Ensure the modification is visible on filesystems with coarse timestamps
+
+	select {
+	case changed := <-reloaded:
+		if !reflect.DeepEqual(changed, []string{"LogLevel"}) {
+			t.Errorf("Expected LogLevel to change, got %v", changed)
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("Timed out waiting for the watcher to reload")
+	}
+}
+
+func TestWatcher_Reload_Overrides(t *testing.T) {
+	varsFile := writeVarsFile(t, "vars.yml", "LogLevel: INFO\n")
+	writeDirectory := t.TempDir()
+	initial := &GlobalOpts{VarsFile: varsFile, WriteDirectory: writeDirectory}
+	if err := initial.Init(); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	if source := initial.Provenance()["WriteDirectory"]; source != SourceCode {
+		t.Errorf("WriteDirectory source = %s, Expected: %s", source, SourceCode)
+	}
+
+	w := NewWatcher(initial)
+	ioutil.WriteFile(varsFile, []byte("LogLevel: WARN\n"), 0644)
+	if err := w.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if current := w.Config(); current.LogLevel != "WARN" || current.WriteDirectory != writeDirectory {
+		t.Errorf("Expected the override to be kept after a reload, got WriteDirectory = %s", current.WriteDirectory)
+	}
+}
+
+func TestWatcher_Reload_RotatedSecret(t *testing.T) {
+	key := make([]byte, 32)
+	os.Setenv(secret.KeyEnvVar, base64.StdEncoding.EncodeToString(key))
+	defer os.Unsetenv(secret.KeyEnvVar)
+	secretsFile := filepath.Join(t.TempDir(), "secrets.enc")
+	rotate := func(value string) {
+		data, _ := secret.EncryptSecrets(key, map[string]string{"ClientSecret": value})
+		ioutil.WriteFile(secretsFile, data, 0600)
+	}
+
+	rotate("original")
+	varsFile := writeVarsFile(t, "vars.yml", "CloudProviders:\n  Azure:\n    ClientSecret: "+secret.EncryptedPrefix+secretsFile+"#ClientSecret\n")
+	initial := &GlobalOpts{VarsFile: varsFile}
+	if err := initial.Init(); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+
+	rotate("rotated")
+	w := NewWatcher(initial)
+	if err := w.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if got := w.Config().CloudProviders.Azure.ClientSecret; got != "rotated" {
+		t.Errorf("ClientSecret = %s after reload, Expected: rotated", got)
+	}
+}
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// SampleVarsFile generates a commented vars file from the yaml tags of v, such as GlobalOpts{}.
+// Each value is described using its doc, env, default, validate and secret tags, and is set to its default.
+// Values with the secret tag, which are the only ones resolved from secret references, are marked Secret.
+// Lists of structs are shown as a commented example entry.
+func SampleVarsFile(v interface{}) []byte {
+	t := reflect.TypeOf(v)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	var b bytes.Buffer
+	b.WriteString("# Sample vars file. Each value is shown with its default, where one exists.\n")
+	b.WriteString("# Only values marked Secret may instead be a secret reference, such as env:OTHER_VAR or file:///run/secrets/name\n")
+	writeSample(&b, t, "")
+	return b.Bytes()
+}
+
+func writeSample(b *bytes.Buffer, t reflect.Type, indent string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := yamlName(f)
+		if f.PkgPath != "" || name == "" || name == "-" {
+			continue
+		}
+		for _, line := range sampleComments(f) {
+			fmt.Fprintf(b, "%s# %s\n", indent, line)
+		}
+		switch {
+		case f.Type.Kind() == reflect.Struct:
+			fmt.Fprintf(b, "%s%s:\n", indent, name)
+			writeSample(b, f.Type, indent+"  ")
+		case f.Type.Kind() == reflect.Slice && f.Type.Elem().Kind() == reflect.Struct:
+			fmt.Fprintf(b, "%s%s: []\n", indent, name)
+			var entry bytes.Buffer
+			writeSample(&entry, f.Type.Elem(), "")
+			for j, line := range strings.Split(strings.TrimRight(entry.String(), "\n"), "\n") {
+				marker := "  "
+				if j == 0 {
+					marker = "- "
+				}
+				fmt.Fprintf(b, "%s#   %s%s\n", indent, marker, line)
+			}
+		default:
+			fmt.Fprintf(b, "%s%s: %s\n", indent, name, sampleValue(f))
+		}
+	}
+}
+
+func sampleComments(f reflect.StructField) (lines []string) {
+	if doc := f.Tag.Get("doc"); doc != "" {
+		lines = append(lines, doc)
+	}
+	var details []string
+	if env := f.Tag.Get("env"); env != "" {
+		details = append(details, "Env: "+env)
+	}
+	for _, rule := range strings.Split(f.Tag.Get("validate"), ",") {
+		switch {
+		case rule == "required":
+			details = append(details, "Required")
+		case strings.HasPrefix(rule, "oneof="):
+			details = append(details, "One of: "+strings.Join(strings.Split(strings.TrimPrefix(rule, "oneof="), "|"), ", "))
+		case rule == "path":
+			details = append(details, "Must be an existing path")
+		case rule == "url":
+			details = append(details, "Must be a URL")
+		case rule == "uuid":
+			details = append(details, "Must be a UUID")
+		}
+	}
+	if f.Tag.Get("secret") == "true" {
+		details = append(details, "Secret; consider using a secret reference")
+	}
+	if len(details) > 0 {
+		lines = append(lines, strings.Join(details, ". "))
+	}
+	return
+}
+
+// sampleValue renders the default tag, or the zero value for the field's type
+func sampleValue(f reflect.StructField) string {
+	value := reflect.New(f.Type).Elem().Interface()
+	if def := f.Tag.Get("default"); def != "" {
+		value = def
+		if f.Type.Kind() != reflect.String {
+			return def
+		}
+	}
+	data, err := yaml.Marshal(value)
+	if err != nil {
+		return `""`
+	}
+	return strings.TrimSpace(string(data))
+}
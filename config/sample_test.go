@@ -0,0 +1,28 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestSampleVarsFile(t *testing.T) {
+	sample := SampleVarsFile(GlobalOpts{})
+
+	var decoded GlobalOpts
+	if err := yaml.Unmarshal(sample, &decoded); err != nil {
+		t.Fatalf("Sample vars file is not valid YAML: %v", err)
+	}
+	if decoded.LogLevel != "DEBUG" || decoded.GodogResultsFormat != "cucumber" {
+		t.Errorf("Sample vars file did not contain defaults: %+v", decoded)
+	}
+	if err := decoded.Validate(); err != nil {
+		t.Errorf("Sample vars file should pass validation: %v", err)
+	}
+	for _, expected := range []string{"# Env: PROBR_LOG_LEVEL", "One of: TRACE, DEBUG", "#     Type: \"\"", "    TenantID: \"\"", "Secret; consider", "Only values marked Secret"} {
+		if !strings.Contains(string(sample), expected) {
+			t.Errorf("Sample vars file did not contain '%s'", expected)
+		}
+	}
+}
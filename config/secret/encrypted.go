@@ -0,0 +1,138 @@
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v2"
+)
+
+// KeyEnvVar contains the base64 encoded 32 byte key used by the default EncryptedFileResolver
+const KeyEnvVar = "PROBR_SECRETS_KEY"
+
+// EncryptedFileResolver reads secrets from a local file encrypted with AES-256-GCM, as created by EncryptSecrets.
+// References take the form 'encrypted:///path/to/secrets.enc#name'.
+type EncryptedFileResolver struct {
+	Key []byte // Defaults to the base64 decoded value of KeyEnvVar
+
+	mux   sync.Mutex
+	files map[string]map[string]string
+}
+
+// Resolve decrypts the file, caching its contents until ClearCache is called, and returns the named secret
+func (e *EncryptedFileResolver) Resolve(reference string) (string, error) {
+	parts := strings.SplitN(reference, "#", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return "", fmt.Errorf("expected a reference such as %s/path/to/secrets.enc#name", EncryptedPrefix)
+	}
+	path, name := parts[0], parts[1]
+
+	e.mux.Lock()
+	defer e.mux.Unlock()
+	secrets, ok := e.files[path]
+	if !ok {
+		key, err := e.key()
+		if err != nil {
+			return "", err
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		secrets, err = DecryptSecrets(key, data)
+		if err != nil {
+			return "", fmt.Errorf("unable to decrypt %s: %v", path, err)
+		}
+		if e.files == nil {
+			e.files = make(map[string]map[string]string)
+		}
+		e.files[path] = secrets
+	}
+	value, ok := secrets[name]
+	if !ok {
+		return "", fmt.Errorf("secret '%s' was not found in %s", name, path)
+	}
+	return value, nil
+}
+
+// ClearCache discards the decrypted files, so that each is read again the next time it is resolved
+func (e *EncryptedFileResolver) ClearCache() {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+	e.files = nil
+}
+
+func (e *EncryptedFileResolver) key() ([]byte, error) {
+	if e.Key != nil {
+		return e.Key, nil
+	}
+	encoded := os.Getenv(KeyEnvVar)
+	if encoded == "" {
+		return nil, fmt.Errorf("%s must be set to decrypt secrets files", KeyEnvVar)
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not valid base64: %v", KeyEnvVar, err)
+	}
+	return key, nil
+}
+
+// EncryptSecrets returns the contents of an encrypted secrets file for use with EncryptedFileResolver.
+// Key must be 32 bytes long.
+func EncryptSecrets(key []byte, secrets map[string]string) ([]byte, error) {
+	plaintext, err := yaml.Marshal(secrets)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	sealed := gcm.Seal(nonce, nonce, plaintext, nil)
+	return []byte(base64.StdEncoding.EncodeToString(sealed)), nil
+}
+
+// DecryptSecrets reads the contents of a file created by EncryptSecrets
+func DecryptSecrets(key []byte, data []byte) (map[string]string, error) {
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, fmt.Errorf("file is too short to be encrypted secrets")
+	}
+	plaintext, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, err
+	}
+	secrets := make(map[string]string)
+	err = yaml.Unmarshal(plaintext, &secrets)
+	return secrets, err
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("key must be 32 bytes for AES-256 but found %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
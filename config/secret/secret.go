@@ -0,0 +1,107 @@
+// Package secret resolves references in config values, such as 'file:///run/secrets/x' or 'env:OTHER_VAR',
+// so that secrets never need to be written into a vars file.
+package secret
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// SecretResolver returns the secret for a reference, where the reference has had its prefix removed
+type SecretResolver interface {
+	Resolve(reference string) (string, error)
+}
+
+// ResolverFunc allows a function to be used as a SecretResolver
+type ResolverFunc func(reference string) (string, error)
+
+// Resolve calls f(reference)
+func (f ResolverFunc) Resolve(reference string) (string, error) {
+	return f(reference)
+}
+
+// Prefixes for the built-in resolvers
+const (
+	FilePrefix      = "file://"
+	EnvPrefix       = "env:"
+	EncryptedPrefix = "encrypted://"
+)
+
+var (
+	resolversMux sync.RWMutex
+	resolvers    = map[string]SecretResolver{
+		FilePrefix:      ResolverFunc(resolveFile),
+		EnvPrefix:       ResolverFunc(resolveEnv),
+		EncryptedPrefix: &EncryptedFileResolver{},
+	}
+)
+
+// CachingResolver is implemented by resolvers that cache secrets, such as EncryptedFileResolver,
+// so that their cache can be discarded by ClearCache when secrets may have been rotated
+type CachingResolver interface {
+	ClearCache()
+}
+
+// Register adds a resolver for values beginning with prefix, such as "vault://".
+// Registering an existing prefix replaces that resolver.
+func Register(prefix string, r SecretResolver) {
+	resolversMux.Lock()
+	defer resolversMux.Unlock()
+	resolvers[prefix] = r
+}
+
+// Resolve returns the secret for value if it begins with a registered prefix.
+// Values without a registered prefix are returned unchanged, with isReference set to false.
+func Resolve(value string) (resolved string, isReference bool, err error) {
+	resolversMux.RLock()
+	defer resolversMux.RUnlock()
+	// Check the longest prefixes first, so that a more specific resolver always wins
+	var prefixes []string
+	for prefix := range resolvers {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Slice(prefixes, func(i, j int) bool { return len(prefixes[i]) > len(prefixes[j]) })
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(value, prefix) {
+			resolved, err = resolvers[prefix].Resolve(strings.TrimPrefix(value, prefix))
+			if err != nil {
+				err = fmt.Errorf("failed to resolve '%s': %v", value, err)
+			}
+			return resolved, true, err
+		}
+	}
+	return value, false, nil
+}
+
+// ClearCache discards the secrets cached by each registered CachingResolver, so that they are read again
+// the next time they are resolved. config.Watcher calls this before each reload.
+func ClearCache() {
+	resolversMux.RLock()
+	defer resolversMux.RUnlock()
+	for _, r := range resolvers {
+		if c, ok := r.(CachingResolver); ok {
+			c.ClearCache()
+		}
+	}
+}
+
+// resolveFile reads a secret such as those mounted by Docker or Kubernetes, ignoring any trailing newline
+func resolveFile(path string) (string, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
+
+func resolveEnv(name string) (string, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("env var %s is not set", name)
+	}
+	return value, nil
+}
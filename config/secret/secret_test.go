@@ -0,0 +1,98 @@
+package secret
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	dir := t.TempDir()
+	secretFile := filepath.Join(dir, "client-secret")
+	if err := ioutil.WriteFile(secretFile, []byte("from-file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("PROBR_TEST_SECRET", "from-env")
+	defer os.Unsetenv("PROBR_TEST_SECRET")
+
+	key := bytes.Repeat([]byte{7}, 32)
+	data, err := EncryptSecrets(key, map[string]string{"ClientSecret": "from-encrypted"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	encryptedFile := filepath.Join(dir, "secrets.enc")
+	if err = ioutil.WriteFile(encryptedFile, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	Register("test-encrypted://", &EncryptedFileResolver{Key: key})
+	Register("test://", ResolverFunc(func(reference string) (string, error) { return "custom-" + reference, nil }))
+
+	tests := []struct {
+		value, expected string
+		isReference     bool
+		expectErr       bool
+	}{
+		{"plain value", "plain value", false, false},
+		{FilePrefix + secretFile, "from-file", true, false},
+		{EnvPrefix + "PROBR_TEST_SECRET", "from-env", true, false},
+		{"test-encrypted://" + encryptedFile + "#ClientSecret", "from-encrypted", true, false},
+		{"test://name", "custom-name", true, false},
+		{EnvPrefix + "PROBR_TEST_SECRET_UNSET", "", true, true},
+		{FilePrefix + filepath.Join(dir, "missing"), "", true, true},
+		{"test-encrypted://" + encryptedFile + "#Missing", "", true, true},
+		{"test-encrypted://" + encryptedFile, "", true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, isReference, err := Resolve(tt.value)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("Resolve() error = %v, expectErr %v", err, tt.expectErr)
+			}
+			if isReference != tt.isReference || (!tt.expectErr && got != tt.expected) {
+				t.Errorf("Resolve() = %v, %v; expected %v, %v", got, isReference, tt.expected, tt.isReference)
+			}
+		})
+	}
+}
+
+func TestDecryptSecrets_WrongKey(t *testing.T) {
+	data, err := EncryptSecrets(bytes.Repeat([]byte{1}, 32), map[string]string{"a": "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = DecryptSecrets(bytes.Repeat([]byte{2}, 32), data); err == nil {
+		t.Errorf("Expected an error when decrypting with the wrong key")
+	}
+	if _, err = EncryptSecrets([]byte("short"), nil); err == nil {
+		t.Errorf("Expected an error for a key that is not 32 bytes")
+	}
+}
+
+func TestClearCache(t *testing.T) {
+	key := bytes.Repeat([]byte{3}, 32)
+	path := filepath.Join(t.TempDir(), "secrets.enc")
+	write := func(value string) {
+		data, err := EncryptSecrets(key, map[string]string{"ClientSecret": value})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = ioutil.WriteFile(path, data, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	Register("test-rotated://", &EncryptedFileResolver{Key: key})
+	reference := "test-rotated://" + path + "#ClientSecret"
+
+	write("original")
+	Resolve(reference)
+	write("rotated")
+	if got, _, _ := Resolve(reference); got != "original" {
+		t.Errorf("Expected the decrypted file to be cached, got %s", got)
+	}
+	ClearCache()
+	if got, _, err := Resolve(reference); got != "rotated" {
+		t.Errorf("Expected the rotated secret after ClearCache, got %s, %v", got, err)
+	}
+}
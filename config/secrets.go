@@ -0,0 +1,74 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/probr/probr-sdk/config/secret"
+	"github.com/probr/probr-sdk/config/validator"
+)
+
+// resolveSecrets replaces each value tagged `secret:"true"` that is a secret reference, such as 'file:///run/secrets/x'
+// or 'env:OTHER_VAR', with the secret it refers to. Strings within tagged slices, maps and structs are included.
+// Other values are never resolved, so that values such as URLs may begin with a secret prefix.
+func (ctx *GlobalOpts) resolveSecrets() error {
+	ctx.secrets = make(map[string]bool)
+	var problems validator.Errors
+	ctx.resolveValue("", reflect.ValueOf(ctx).Elem(), false, &problems)
+	for _, key := range ctx.sectionKeys() {
+		ctx.resolveValue(key, reflect.ValueOf(ctx.sections[key]).Elem(), false, &problems)
+	}
+	return problems.Err()
+}
+
+func (ctx *GlobalOpts) resolveValue(path string, v reflect.Value, isSecret bool, problems *validator.Errors) {
+	switch v.Kind() {
+	case reflect.String:
+		if !isSecret {
+			return
+		}
+		resolved, isReference, err := secret.Resolve(v.String())
+		if err != nil {
+			*problems = append(*problems, validator.FieldError{Field: path, Rule: "secret", Message: err.Error()})
+		} else if isReference {
+			v.SetString(resolved)
+			ctx.secrets[path] = true
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			if f := t.Field(i); f.PkgPath == "" { // exported
+				ctx.resolveValue(joinPath(path, fieldPath(f)), v.Field(i), isSecret || f.Tag.Get("secret") == "true", problems)
+			}
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			ctx.resolveValue(fmt.Sprintf("%s[%d]", path, i), v.Index(i), isSecret, problems)
+		}
+	case reflect.Map:
+		if v.Type().Elem().Kind() != reflect.String {
+			return
+		}
+		for _, key := range v.MapKeys() {
+			value := reflect.New(v.Type().Elem()).Elem()
+			value.Set(v.MapIndex(key))
+			ctx.resolveValue(fmt.Sprintf("%s.%v", path, key), value, isSecret, problems)
+			v.SetMapIndex(key, value)
+		}
+	}
+}
+
+// fieldPath prefers the YAML name, so that problems refer to the keys a user would write in a vars file
+func fieldPath(f reflect.StructField) string {
+	if name := yamlName(f); name != "" && name != "-" {
+		return name
+	}
+	return f.Name
+}
+
+func joinPath(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
@@ -0,0 +1,187 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/probr/probr-sdk/config/setter"
+	"github.com/probr/probr-sdk/config/validator"
+)
+
+// Suggested namespaces for config sections
+const (
+	ProvidersNamespace    = "Providers"
+	ServicePacksNamespace = "ServicePacks"
+)
+
+// RegisterSection adds a config section to GlobalConfig. See GlobalOpts.RegisterSection.
+func RegisterSection(key string, section interface{}) error {
+	return GlobalConfig.RegisterSection(key, section)
+}
+
+// RegisterSection allows a provider or service pack to contribute its own config struct, decoded from the
+// vars files beneath a namespaced key such as "ServicePacks.Kubernetes". Section must be a pointer to a struct.
+// The section takes part in every config layer: its env and default tags are applied via setter.SetVars, or via
+// its own SetEnvAndDefaults() error method if it has one, and its validate tags are checked via validator.Validate,
+// or via its own Validate() error method if it has one.
+// Sections should be registered before Init is called.
+func (ctx *GlobalOpts) RegisterSection(key string, section interface{}) error {
+	v := reflect.ValueOf(section)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("config section '%s' must be a pointer to a struct but found %T", key, section)
+	}
+	parts := strings.Split(key, ".")
+	if len(parts) < 2 {
+		return fmt.Errorf("config section '%s' must be namespaced, such as '%s.%s'", key, ServicePacksNamespace, key)
+	}
+	for _, name := range leafKeys("", reflect.TypeOf(*ctx)) {
+		if strings.Split(name, ".")[0] == parts[0] {
+			return fmt.Errorf("config section '%s' conflicts with the existing config value '%s'", key, name)
+		}
+	}
+	for existing := range ctx.sections {
+		if existing == key || strings.HasPrefix(existing, key+".") || strings.HasPrefix(key, existing+".") {
+			return fmt.Errorf("config section '%s' conflicts with the registered section '%s'", key, existing)
+		}
+	}
+	if ctx.sections == nil {
+		ctx.sections = make(map[string]interface{})
+	}
+	ctx.sections[key] = section
+	return nil
+}
+
+// Section returns the config section registered beneath key, or nil
+func (ctx *GlobalOpts) Section(key string) interface{} {
+	return ctx.sections[key]
+}
+
+func (ctx *GlobalOpts) sectionKeys() (keys []string) {
+	for key := range ctx.sections {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return
+}
+
+// sectionForKey finds the section containing a dotted config key, returning the key relative to that section
+func (ctx *GlobalOpts) sectionForKey(key string) (section interface{}, relative string) {
+	for sectionKey, s := range ctx.sections {
+		if strings.HasPrefix(key, sectionKey+".") {
+			return s, strings.TrimPrefix(key, sectionKey+".")
+		}
+	}
+	return nil, ""
+}
+
+// applySectionLayers decodes the subtree of a vars file layer beneath each section key onto that section
+func (ctx *GlobalOpts) applySectionLayers(layer map[interface{}]interface{}, source string) error {
+	for _, key := range ctx.sectionKeys() {
+		subtree, ok := lookupPath(layer, strings.Split(key, "."))
+		if !ok {
+			continue
+		}
+		data, err := yaml.Marshal(subtree)
+		if err != nil {
+			return err
+		}
+		if err = yaml.Unmarshal(data, ctx.sections[key]); err != nil {
+			return fmt.Errorf("failed to apply config section %s from %s: %v", key, source, err)
+		}
+	}
+	return nil
+}
+
+func lookupPath(m map[interface{}]interface{}, path []string) (interface{}, bool) {
+	value, ok := m[path[0]]
+	if !ok || len(path) == 1 {
+		return value, ok
+	}
+	nested, ok := value.(map[interface{}]interface{})
+	if !ok {
+		return nil, false
+	}
+	return lookupPath(nested, path[1:])
+}
+
+// setSectionEnvAndDefaults applies the env and default tags of each section, or its own SetEnvAndDefaults method
+func (ctx *GlobalOpts) setSectionEnvAndDefaults() error {
+	var problems []string
+	for _, key := range ctx.sectionKeys() {
+		var err error
+		if s, ok := ctx.sections[key].(interface{ SetEnvAndDefaults() error }); ok {
+			err = s.SetEnvAndDefaults()
+		} else {
+			prefix := key + "."
+			err = setter.SetUnsetVars(ctx.sections[key], func(path string) bool { return ctx.isSet(prefix + path) })
+		}
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", key, err))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// validateSections checks each section via its own Validate method or its validate tags,
+// prefixing each problem with the section key
+func (ctx *GlobalOpts) validateSections() (problems validator.Errors) {
+	for _, key := range ctx.sectionKeys() {
+		var err error
+		if s, ok := ctx.sections[key].(interface{ Validate() error }); ok {
+			err = s.Validate()
+		} else {
+			err = validator.Validate(ctx.sections[key])
+		}
+		if errs, ok := err.(validator.Errors); ok {
+			for _, fe := range errs {
+				fe.Field = joinPath(key, fe.Field)
+				problems = append(problems, fe)
+			}
+		} else {
+			problems = appendProblems(problems, key, err)
+		}
+	}
+	return
+}
+
+// withSections adds the YAML for each section beneath its key
+func (ctx *GlobalOpts) withSections(config yaml.MapSlice) (yaml.MapSlice, error) {
+	for _, key := range ctx.sectionKeys() {
+		data, err := yaml.Marshal(ctx.sections[key])
+		if err != nil {
+			return nil, err
+		}
+		var section yaml.MapSlice
+		if err = yaml.Unmarshal(data, &section); err != nil {
+			return nil, err
+		}
+		config = setPath(config, strings.Split(key, "."), section)
+	}
+	return config, nil
+}
+
+func setPath(m yaml.MapSlice, path []string, value interface{}) yaml.MapSlice {
+	for i, item := range m {
+		if item.Key != path[0] {
+			continue
+		}
+		if len(path) == 1 {
+			m[i].Value = value
+		} else {
+			nested, _ := item.Value.(yaml.MapSlice)
+			m[i].Value = setPath(nested, path[1:], value)
+		}
+		return m
+	}
+	if len(path) == 1 {
+		return append(m, yaml.MapItem{Key: path[0], Value: value})
+	}
+	return append(m, yaml.MapItem{Key: path[0], Value: setPath(nil, path[1:], value)})
+}
@@ -0,0 +1,126 @@
+package config
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/probr/probr-sdk/config/validator"
+)
+
+type testSection struct {
+	Namespace string            `yaml:"Namespace" env:"PROBR_TEST_SECTION_NAMESPACE" default:"probr"`
+	Replicas  int               `yaml:"Replicas" default:"1"`
+	Token     string            `yaml:"Token" secret:"true"`
+	Endpoint  string            `yaml:"Endpoint" validate:"url"`
+	Labels    map[string]string `yaml:"Labels"`
+}
+
+func TestGlobalOpts_RegisterSection(t *testing.T) {
+	varsFile := writeVarsFile(t, "vars.yml", `
+LogLevel: INFO
+ServicePacks:
+  Test:
+    Replicas: 3
+    Token: plaintext-token
+    Endpoint: not-a-url
+    Labels:
+      team: probr
+Profiles:
+  prod:
+    ServicePacks:
+      Test:
+        Replicas: 5
+`)
+	os.Setenv("PROBR_TEST_SECTION_NAMESPACE", "from-env")
+	defer os.Unsetenv("PROBR_TEST_SECTION_NAMESPACE")
+
+	section := &testSection{}
+	ctx := GlobalOpts{VarsFile: varsFile, Profile: "prod"}
+	if err := ctx.RegisterSection("ServicePacks.Test", section); err != nil {
+		t.Fatalf("RegisterSection() error = %v", err)
+	}
+	if err := ctx.SetFlag("ServicePacks.Test.Labels", "team=flag"); err != nil {
+		t.Fatalf("SetFlag() error = %v", err)
+	}
+
+	err := ctx.Init()
+	errs, ok := err.(validator.Errors)
+	if !ok || len(errs) != 1 || errs[0].Field != "ServicePacks.Test.Endpoint" {
+		t.Errorf("Init() expected a single problem for ServicePacks.Test.Endpoint, got: %v", err)
+	}
+	if section.Replicas != 5 || section.Namespace != "from-env" || section.Labels["team"] != "flag" || section.Token != "plaintext-token" {
+		t.Errorf("Section was not populated from each layer: %+v", section)
+	}
+	if ctx.Section("ServicePacks.Test") != section {
+		t.Errorf("Section() did not return the registered section")
+	}
+
+	provenance := ctx.Provenance()
+	expected := map[string]string{
+		"ServicePacks.Test.Replicas":  "profile:prod",
+		"ServicePacks.Test.Namespace": "env:PROBR_TEST_SECTION_NAMESPACE",
+		"ServicePacks.Test.Labels":    "flag:ServicePacks.Test.Labels",
+		"ServicePacks.Test.Token":     "file:" + varsFile,
+	}
+	for key, source := range expected {
+		if provenance[key] != source {
+			t.Errorf("Provenance()[%s] = %s, expected %s", key, provenance[key], source)
+		}
+	}
+
+	effective, err := ctx.EffectiveConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(effective), "Token: <redacted>") || !strings.Contains(string(effective), "Replicas: 5") {
+		t.Errorf("Effective config does not contain the redacted section:\n%s", effective)
+	}
+}
+
+func TestGlobalOpts_RegisterSection_Conflicts(t *testing.T) {
+	ctx := GlobalOpts{}
+	if err := ctx.RegisterSection("ServicePacks.Test", &testSection{}); err != nil {
+		t.Fatalf("RegisterSection() error = %v", err)
+	}
+	tests := []struct {
+		key     string
+		section interface{}
+	}{
+		{"ServicePacks.Test", &testSection{}},
+		{"ServicePacks.Test.Nested", &testSection{}},
+		{"Test", &testSection{}},
+		{"CloudProviders.Kubernetes", &testSection{}},
+		{"ServicePacks.Other", testSection{}},
+	}
+	for _, tt := range tests {
+		if err := ctx.RegisterSection(tt.key, tt.section); err == nil {
+			t.Errorf("RegisterSection(%s, %T) expected an error", tt.key, tt.section)
+		}
+	}
+}
+
+func TestGlobalOpts_RegisterSection_ExplicitFalse(t *testing.T) {
+	type toggles struct {
+		FromFile bool `yaml:"FromFile" default:"true"`
+		FromEnv  bool `yaml:"FromEnv" env:"PROBR_TEST_SECTION_FROM_ENV" default:"true"`
+		Unset    bool `yaml:"Unset" default:"true"`
+	}
+	varsFile := writeVarsFile(t, "vars.yml", `
+ServicePacks:
+  Toggles:
+    FromFile: false
+`)
+	os.Setenv("PROBR_TEST_SECTION_FROM_ENV", "false")
+	defer os.Unsetenv("PROBR_TEST_SECTION_FROM_ENV")
+
+	section := &toggles{}
+	ctx := GlobalOpts{VarsFile: varsFile}
+	if err := ctx.RegisterSection("ServicePacks.Toggles", section); err != nil {
+		t.Fatalf("RegisterSection() error = %v", err)
+	}
+	ctx.Init()
+	if section.FromFile || section.FromEnv || !section.Unset {
+		t.Errorf("Defaults should only apply to values that were not set, got: %+v", section)
+	}
+}
@@ -1,20 +1,214 @@
 package setter
 
 import (
-	"log"
+	"fmt"
 	"os"
+	"reflect"
+	"strconv"
 	"strings"
+	"time"
 )
 
-// SetVar fetches the env var or sets the default value as needed for the specified field from VarOptions
-func SetVar(field interface{}, varName string, defaultValue interface{}) {
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// SetVar fetches the env var or sets the default value as needed for the specified field from VarOptions.
+// The field is only set if it holds its zero value; see SetUnsetVars to keep values explicitly set to zero, such as false.
+// Field must be a pointer to a string, bool, int, float, time.Duration, []string or map[string]string.
+// The default value may be provided either as the field's type or as a string to be parsed.
+func SetVar(field interface{}, varName string, defaultValue interface{}) error {
 	switch v := field.(type) {
 	case *string:
-		*field.(*string) = setStringVar(*field.(*string), varName, defaultValue.(string))
+		d, ok := defaultValue.(string)
+		if !ok {
+			return fmt.Errorf("unexpected default value type provided for '%v'. Found %T but expected string", varName, defaultValue)
+		}
+		*v = setStringVar(*v, varName, d)
+		return nil
 	case *[]string:
-		*field.(*[]string) = setStringSliceVar(*field.(*[]string), varName, defaultValue.([]string))
+		if d, ok := defaultValue.([]string); ok {
+			*v = setStringSliceVar(*v, varName, d)
+			return nil
+		}
+	}
+
+	ptr := reflect.ValueOf(field)
+	if ptr.Kind() != reflect.Ptr || ptr.IsNil() {
+		return fmt.Errorf("unexpected value type provided for '%v'. Found %T but expected a pointer", varName, field)
+	}
+	if !ptr.Elem().IsZero() {
+		return nil
+	}
+	return setEnvOrDefault(ptr.Elem(), varName, defaultValue)
+}
+
+// setEnvOrDefault sets the value from the env var if it is not empty, or otherwise from the default value
+func setEnvOrDefault(value reflect.Value, varName string, defaultValue interface{}) error {
+	if env := os.Getenv(varName); varName != "" && env != "" {
+		field := value.Addr().Interface()
+		if err := SetString(field, env); err != nil {
+			return fmt.Errorf("invalid value for env var '%s': %v", varName, err)
+		}
+		return nil
+	}
+	return setDefault(value, varName, defaultValue)
+}
+
+func setDefault(value reflect.Value, varName string, defaultValue interface{}) error {
+	if defaultValue == nil {
+		return nil
+	}
+	if s, ok := defaultValue.(string); ok && value.Kind() != reflect.String {
+		if s == "" {
+			return nil
+		}
+		if err := SetString(value.Addr().Interface(), s); err != nil {
+			return fmt.Errorf("invalid default value for '%s': %v", varName, err)
+		}
+		return nil
+	}
+	d := reflect.ValueOf(defaultValue)
+	if !d.Type().AssignableTo(value.Type()) {
+		return fmt.Errorf("unexpected default value type provided for '%v'. Found %T but expected %v", varName, defaultValue, value.Type())
+	}
+	value.Set(d)
+	return nil
+}
+
+// SetString parses raw according to the type of the field that ptr points to, then sets the field.
+// []string values are comma separated, and map[string]string values are comma separated key=value pairs.
+func SetString(ptr interface{}, raw string) error {
+	p := reflect.ValueOf(ptr)
+	if p.Kind() != reflect.Ptr || p.IsNil() {
+		return fmt.Errorf("found %T but expected a pointer", ptr)
+	}
+	value := p.Elem()
+
+	if value.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		value.SetInt(int64(d))
+		return nil
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		value.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(raw, 10, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(raw, 10, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(raw, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetFloat(f)
+	case reflect.Slice:
+		if value.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported type %v", value.Type())
+		}
+		value.Set(reflect.ValueOf(strings.Split(raw, ",")).Convert(value.Type()))
+	case reflect.Map:
+		if value.Type().Key().Kind() != reflect.String || value.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported type %v", value.Type())
+		}
+		m, err := parseMap(raw)
+		if err != nil {
+			return err
+		}
+		value.Set(reflect.ValueOf(m).Convert(value.Type()))
 	default:
-		log.Fatalf("Unexpected value type provided for '%v'. Found %T but expected *string or *[]string", varName, v)
+		return fmt.Errorf("unsupported type %v", value.Type())
+	}
+	return nil
+}
+
+// parseMap reads comma separated key=value pairs, such as "team=probr,env=dev"
+func parseMap(raw string) (map[string]string, error) {
+	m := make(map[string]string)
+	for _, pair := range strings.Split(raw, ",") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, fmt.Errorf("expected key=value but found '%s'", pair)
+		}
+		m[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+	return m, nil
+}
+
+// SetVars walks the struct that ptr points to and calls SetVar for each field with an 'env' or 'default' tag,
+// such as `env:"PROBR_KEEP_PODS" default:"false"`. Nested structs are walked recursively.
+// All problems are returned together rather than stopping at the first.
+// Fields are only set if they hold their zero value, as for SetVar.
+func SetVars(ptr interface{}) error {
+	return SetUnsetVars(ptr, nil)
+}
+
+// SetUnsetVars walks the struct as SetVars does, but sets each field for which isSet returns false, whatever its value,
+// so that values explicitly set to their zero value, such as a bool set to false over a default of true, are kept.
+// isSet is passed the dotted path of each field's yaml names, such as "Logging.Compress". Fields without a yaml name,
+// or every field if isSet is nil, are only set if they hold their zero value.
+func SetUnsetVars(ptr interface{}, isSet func(path string) bool) error {
+	p := reflect.ValueOf(ptr)
+	if p.Kind() != reflect.Ptr || p.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("found %T but expected a pointer to a struct", ptr)
+	}
+	var problems []string
+	setStructVars(p.Elem(), "", isSet, &problems)
+	if len(problems) > 0 {
+		return fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func setStructVars(v reflect.Value, prefix string, isSet func(path string) bool, problems *[]string) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" { // unexported
+			continue
+		}
+		field := v.Field(i)
+		name := strings.Split(f.Tag.Get("yaml"), ",")[0]
+		path := prefix + name
+		tracked := isSet != nil && name != "" && name != "-"
+		env, hasEnv := f.Tag.Lookup("env")
+		def, hasDefault := f.Tag.Lookup("default")
+		if hasEnv || hasDefault {
+			var err error
+			if !tracked {
+				err = SetVar(field.Addr().Interface(), env, def)
+			} else if !isSet(path) {
+				err = setEnvOrDefault(field, env, def)
+			}
+			if err != nil {
+				*problems = append(*problems, fmt.Sprintf("%s: %v", f.Name, err))
+			}
+			continue
+		}
+		if field.Kind() == reflect.Struct {
+			if tracked {
+				setStructVars(field, path+".", isSet, problems)
+			} else {
+				setStructVars(field, "", nil, problems)
+			}
+		}
 	}
 }
 
@@ -3,7 +3,9 @@ package setter
 import (
 	"os"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 // TestSetStringVar ...
@@ -185,3 +187,111 @@ func Test_setStringSliceVar(t *testing.T) {
 		})
 	}
 }
+
+func TestSetVar(t *testing.T) {
+	os.Setenv("PROBR_TEST_BOOL", "true")
+	os.Setenv("PROBR_TEST_BAD_INT", "seven")
+	defer os.Unsetenv("PROBR_TEST_BOOL")
+	defer os.Unsetenv("PROBR_TEST_BAD_INT")
+
+	var b bool
+	if err := SetVar(&b, "PROBR_TEST_BOOL", false); err != nil || !b {
+		t.Errorf("SetVar() bool = %v, err = %v; expected true from env", b, err)
+	}
+
+	var i int
+	if err := SetVar(&i, "PROBR_TEST_UNSET", "42"); err != nil || i != 42 {
+		t.Errorf("SetVar() int = %v, err = %v; expected 42 from string default", i, err)
+	}
+	if err := SetVar(&i, "PROBR_TEST_BAD_INT", 1); err != nil || i != 42 {
+		t.Errorf("SetVar() should not override a value that is already set; got %v, err = %v", i, err)
+	}
+
+	var bad int
+	if err := SetVar(&bad, "PROBR_TEST_BAD_INT", 1); err == nil {
+		t.Errorf("SetVar() expected an error for an unparseable env var")
+	}
+
+	var d time.Duration
+	if err := SetVar(&d, "PROBR_TEST_UNSET", 5*time.Second); err != nil || d != 5*time.Second {
+		t.Errorf("SetVar() duration = %v, err = %v; expected 5s from typed default", d, err)
+	}
+
+	var s string
+	if err := SetVar(&s, "PROBR_TEST_UNSET", 5); err == nil {
+		t.Errorf("SetVar() expected an error for a mismatched default type")
+	}
+}
+
+func TestSetString(t *testing.T) {
+	var m map[string]string
+	if err := SetString(&m, "team=probr, env=dev"); err != nil {
+		t.Fatalf("SetString() unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(m, map[string]string{"team": "probr", "env": "dev"}) {
+		t.Errorf("SetString() map = %v", m)
+	}
+	if err := SetString(&m, "missing-equals"); err == nil {
+		t.Errorf("SetString() expected an error for an invalid map value")
+	}
+
+	var f float64
+	if err := SetString(&f, "0.5"); err != nil || f != 0.5 {
+		t.Errorf("SetString() float = %v, err = %v", f, err)
+	}
+	var unsupported []int
+	if err := SetString(&unsupported, "1,2"); err == nil {
+		t.Errorf("SetString() expected an error for an unsupported type")
+	}
+}
+
+func TestSetVars(t *testing.T) {
+	os.Setenv("PROBR_TEST_KEEP", "true")
+	os.Setenv("PROBR_TEST_RETRIES", "three")
+	defer os.Unsetenv("PROBR_TEST_KEEP")
+	defer os.Unsetenv("PROBR_TEST_RETRIES")
+
+	type nested struct {
+		Timeout time.Duration `env:"PROBR_TEST_TIMEOUT" default:"30s"`
+	}
+	opts := struct {
+		Keep    bool              `env:"PROBR_TEST_KEEP" default:"false"`
+		Name    string            `default:"probr"`
+		Labels  map[string]string `default:"a=1"`
+		Retries int               `env:"PROBR_TEST_RETRIES" default:"2"`
+		Nested  nested
+		ignored string
+	}{}
+
+	err := SetVars(&opts)
+	if err == nil || !strings.Contains(err.Error(), "Retries") {
+		t.Errorf("SetVars() expected an error naming Retries, got: %v", err)
+	}
+	if !opts.Keep || opts.Name != "probr" || opts.Labels["a"] != "1" || opts.Nested.Timeout != 30*time.Second {
+		t.Errorf("SetVars() did not set all valid fields: %+v", opts)
+	}
+	if err := SetVars(opts); err == nil {
+		t.Errorf("SetVars() expected an error when not provided a pointer")
+	}
+}
+
+func TestSetUnsetVars(t *testing.T) {
+	type nested struct {
+		Compress bool `yaml:"Compress" default:"true"`
+	}
+	opts := struct {
+		Keep    bool   `yaml:"Keep" default:"true"`
+		Name    string `yaml:"Name" default:"probr"`
+		Retries int    `yaml:"-" default:"2"`
+		Nested  nested `yaml:"Nested"`
+	}{Retries: 5}
+
+	set := map[string]bool{"Keep": true, "Nested.Compress": true}
+	err := SetUnsetVars(&opts, func(path string) bool { return set[path] })
+	if err != nil {
+		t.Fatalf("SetUnsetVars() error = %v", err)
+	}
+	if opts.Keep || opts.Nested.Compress || opts.Name != "probr" || opts.Retries != 5 {
+		t.Errorf("SetUnsetVars() should only set fields that are not set, got: %+v", opts)
+	}
+}
@@ -0,0 +1,184 @@
+package tags
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// SyntaxError describes why an expression could not be parsed
+type SyntaxError struct {
+	Expression string
+	Column     int // 1-based position of the problem within Expression
+	Message    string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("invalid tag expression '%s': %s at column %d", e.Expression, e.Message, e.Column)
+}
+
+// Parse reads an expression such as "@k-iam and not (@k-iam-001 or @wip)". Tags may omit the leading '@'.
+// 'not' binds most tightly, followed by 'and' then 'or'; parentheses may be used to group terms.
+// An empty expression returns nil, which matches every scenario. Expressions too complex to convert via Godog
+// return a SyntaxError.
+func Parse(expression string) (Expr, error) {
+	p := &parser{expression: expression, tokens: tokenize(expression)}
+	if len(p.tokens) == 0 {
+		return nil, nil
+	}
+	e, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t, ok := p.peek(); ok {
+		if t.text == ")" {
+			return nil, p.errorAt(t, "unexpected ')'")
+		}
+		return nil, p.errorAt(t, fmt.Sprintf("expected 'and' or 'or' before '%s'", t.text))
+	}
+	if _, err := conjunctiveForm(e, false); err != nil {
+		return nil, tooComplex(expression, 1)
+	}
+	return e, nil
+}
+
+type token struct {
+	text   string
+	column int
+}
+
+// tokenize splits an expression into parentheses and whitespace-separated words
+func tokenize(expression string) (tokens []token) {
+	start := -1
+	flush := func(end int) {
+		if start >= 0 {
+			tokens = append(tokens, token{text: expression[start:end], column: start + 1})
+			start = -1
+		}
+	}
+	for i, r := range expression {
+		switch {
+		case r == '(' || r == ')':
+			flush(i)
+			tokens = append(tokens, token{text: string(r), column: i + 1})
+		case unicode.IsSpace(r):
+			flush(i)
+		case start < 0:
+			start = i
+		}
+	}
+	flush(len(expression))
+	return
+}
+
+type parser struct {
+	expression string
+	tokens     []token
+	position   int
+}
+
+func (p *parser) peek() (token, bool) {
+	if p.position >= len(p.tokens) {
+		return token{}, false
+	}
+	return p.tokens[p.position], true
+}
+
+func (p *parser) accept(keyword string) bool {
+	if t, ok := p.peek(); ok && t.text == keyword {
+		p.position++
+		return true
+	}
+	return false
+}
+
+func (p *parser) errorAt(t token, message string) error {
+	return &SyntaxError{Expression: p.expression, Column: t.column, Message: message}
+}
+
+func (p *parser) errorAtEnd(message string) error {
+	return &SyntaxError{Expression: p.expression, Column: len(p.expression) + 1, Message: message}
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	operands, err := p.parseOperands("or", p.parseAnd)
+	if err != nil {
+		return nil, err
+	}
+	return Or(operands...), nil
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	operands, err := p.parseOperands("and", p.parseUnary)
+	if err != nil {
+		return nil, err
+	}
+	return And(operands...), nil
+}
+
+// parseOperands reads one or more operands separated by the keyword
+func (p *parser) parseOperands(keyword string, parseOperand func() (Expr, error)) ([]Expr, error) {
+	var operands []Expr
+	for {
+		e, err := parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, e)
+		if !p.accept(keyword) {
+			return operands, nil
+		}
+	}
+}
+
+func (p *parser) parseUnary() (Expr, error) {
+	t, ok := p.peek()
+	if !ok {
+		if p.position == 0 {
+			return nil, p.errorAtEnd("expected a tag")
+		}
+		return nil, p.errorAtEnd(fmt.Sprintf("expected a tag after '%s'", p.tokens[p.position-1].text))
+	}
+	p.position++
+	switch t.text {
+	case "not":
+		e, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return Not(e), nil
+	case "(":
+		e, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(")") {
+			if next, ok := p.peek(); ok {
+				return nil, p.errorAt(next, fmt.Sprintf("expected ')' before '%s'", next.text))
+			}
+			return nil, p.errorAt(t, "missing ')' to close '('")
+		}
+		return e, nil
+	case ")", "and", "or":
+		return nil, p.errorAt(t, fmt.Sprintf("expected a tag but found '%s'", t.text))
+	}
+	if err := checkName(t.text); err != "" {
+		return nil, p.errorAt(t, err)
+	}
+	return Tag(t.text), nil
+}
+
+// checkName returns a description of the problem if a tag cannot be represented in godog's tag syntax
+func checkName(name string) string {
+	if strings.HasPrefix(name, "~") {
+		return fmt.Sprintf("'~' is not supported, use 'not %s' instead", strings.TrimPrefix(name, "~"))
+	}
+	trimmed := strings.TrimPrefix(name, "@")
+	if trimmed == "" {
+		return "'@' must be followed by a tag name"
+	}
+	if i := strings.IndexAny(trimmed, "@~,&"); i >= 0 {
+		return fmt.Sprintf("tag '%s' may not contain '%c'", name, trimmed[i])
+	}
+	return ""
+}
@@ -0,0 +1,285 @@
+// Package tags parses, builds and evaluates cucumber tag expressions, such as "@k-iam and not (@k-iam-001 or @wip)",
+// and converts them to the syntax expected by the bundled version of godog.
+package tags
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// maxClauses limits the size of the godog form of an expression, which grows exponentially when
+// groups joined by 'or' contain 'and', such as "(@a and @b) or (@c and @d) or ..."
+const maxClauses = 1024
+
+var errTooComplex = errors.New("too complex")
+
+// Expr is a tag expression. A nil Expr has no conditions and matches every scenario.
+type Expr interface {
+	// Evaluate reports whether a scenario with the given tags matches the expression. Tags may omit the leading '@'.
+	Evaluate(tags []string) bool
+	// String returns the expression using 'and', 'or' and 'not', in a form that Parse accepts
+	String() string
+}
+
+type tagExpr string
+
+type notExpr struct {
+	operand Expr
+}
+
+type andExpr []Expr
+
+type orExpr []Expr
+
+// Tag creates an expression matching scenarios with the named tag. The leading '@' is optional.
+// The name is not checked, so user input should be passed to Parse instead.
+func Tag(name string) Expr {
+	return tagExpr(strings.TrimPrefix(name, "@"))
+}
+
+// Not creates an expression matching scenarios that do not match e. Not(nil) is nil.
+func Not(e Expr) Expr {
+	if e == nil {
+		return nil
+	}
+	return notExpr{operand: e}
+}
+
+// And creates an expression matching scenarios that match every operand. Nil operands are ignored.
+func And(operands ...Expr) Expr {
+	if operands = nonNil(operands); len(operands) < 2 {
+		return first(operands)
+	}
+	return andExpr(operands)
+}
+
+// Or creates an expression matching scenarios that match any operand. Nil operands are ignored.
+func Or(operands ...Expr) Expr {
+	if operands = nonNil(operands); len(operands) < 2 {
+		return first(operands)
+	}
+	return orExpr(operands)
+}
+
+func nonNil(operands []Expr) (result []Expr) {
+	for _, e := range operands {
+		if e != nil {
+			result = append(result, e)
+		}
+	}
+	return
+}
+
+func first(operands []Expr) Expr {
+	if len(operands) == 0 {
+		return nil
+	}
+	return operands[0]
+}
+
+// noneTag is a tag name that scenarios are not expected to use, as None requires it to be both present and absent
+const noneTag = "probr-none"
+
+// None creates an expression that matches no scenarios, such as when the tags requested could not be parsed
+func None() Expr {
+	return And(Tag(noneTag), Not(Tag(noneTag)))
+}
+
+// Match reports whether a scenario with the given tags matches e. A nil Expr matches every scenario.
+func Match(e Expr, tags []string) bool {
+	if e == nil {
+		return true
+	}
+	return e.Evaluate(tags)
+}
+
+func (t tagExpr) Evaluate(tags []string) bool {
+	for _, tag := range tags {
+		if strings.TrimPrefix(tag, "@") == string(t) {
+			return true
+		}
+	}
+	return false
+}
+
+func (n notExpr) Evaluate(tags []string) bool {
+	return !n.operand.Evaluate(tags)
+}
+
+func (a andExpr) Evaluate(tags []string) bool {
+	for _, e := range a {
+		if !e.Evaluate(tags) {
+			return false
+		}
+	}
+	return true
+}
+
+func (o orExpr) Evaluate(tags []string) bool {
+	for _, e := range o {
+		if e.Evaluate(tags) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t tagExpr) String() string {
+	return "@" + string(t)
+}
+
+func (n notExpr) String() string {
+	return "not " + group(n.operand)
+}
+
+func (a andExpr) String() string {
+	return join(a, " and ")
+}
+
+func (o orExpr) String() string {
+	return join(o, " or ")
+}
+
+func join(operands []Expr, separator string) string {
+	parts := make([]string, len(operands))
+	for i, e := range operands {
+		parts[i] = group(e)
+	}
+	return strings.Join(parts, separator)
+}
+
+// group wraps compound expressions in parentheses so that the result does not depend on operator precedence
+func group(e Expr) string {
+	switch e.(type) {
+	case andExpr, orExpr:
+		return "(" + e.String() + ")"
+	}
+	return e.String()
+}
+
+// Godog converts e to the syntax used by godog v0.11, which only supports tags joined by ',' (or) within
+// groups joined by '&&' (and), with '~' for negation. The expression is first rewritten in that form,
+// so "@a and not (@b or @c)" becomes "@a && ~@b && ~@c". A nil Expr is converted to an empty string.
+// A SyntaxError is returned if the rewritten expression would have more than 1024 groups.
+func Godog(e Expr) (string, error) {
+	if e == nil {
+		return "", nil
+	}
+	cnf, err := conjunctiveForm(e, false)
+	if err != nil {
+		return "", tooComplex(e.String(), 1)
+	}
+	var clauses []string
+	seen := make(map[string]bool)
+	for _, c := range cnf {
+		s := c.String()
+		if !seen[s] {
+			seen[s] = true
+			clauses = append(clauses, s)
+		}
+	}
+	return strings.Join(clauses, " && "), nil
+}
+
+func tooComplex(expression string, column int) *SyntaxError {
+	return &SyntaxError{
+		Expression: expression,
+		Column:     column,
+		Message:    fmt.Sprintf("expression is too complex to convert for godog, as it expands to more than %d groups", maxClauses),
+	}
+}
+
+type literal struct {
+	name    string
+	negated bool
+}
+
+// clause is a list of literals, any of which must match
+type clause []literal
+
+func (c clause) String() string {
+	parts := make([]string, len(c))
+	for i, l := range c {
+		if l.negated {
+			parts[i] = "~@" + l.name
+		} else {
+			parts[i] = "@" + l.name
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// merge combines two clauses, omitting any literal that is already present
+func (c clause) merge(other clause) clause {
+	merged := append(clause{}, c...)
+	for _, l := range other {
+		found := false
+		for _, existing := range merged {
+			found = found || existing == l
+		}
+		if !found {
+			merged = append(merged, l)
+		}
+	}
+	return merged
+}
+
+// conjunctiveForm rewrites e, or its negation, as a list of clauses that must all match.
+// errTooComplex is returned if there would be more than maxClauses.
+func conjunctiveForm(e Expr, negated bool) ([]clause, error) {
+	switch e := e.(type) {
+	case tagExpr:
+		return []clause{{literal{name: string(e), negated: negated}}}, nil
+	case notExpr:
+		return conjunctiveForm(e.operand, !negated)
+	case andExpr:
+		if negated {
+			return distribute(e, true) // not (a and b) == not a or not b
+		}
+		return concat(e, false)
+	case orExpr:
+		if negated {
+			return concat(e, true) // not (a or b) == not a and not b
+		}
+		return distribute(e, false)
+	}
+	panic(fmt.Sprintf("unknown tag expression type %T", e))
+}
+
+// concat returns the clauses of every operand, for operands that must all match
+func concat(operands []Expr, negated bool) (clauses []clause, err error) {
+	for _, e := range operands {
+		operand, err := conjunctiveForm(e, negated)
+		if err != nil {
+			return nil, err
+		}
+		if clauses = append(clauses, operand...); len(clauses) > maxClauses {
+			return nil, errTooComplex
+		}
+	}
+	return
+}
+
+// distribute returns clauses for operands of which any may match, by combining each clause of every operand
+// with each clause of the others
+func distribute(operands []Expr, negated bool) ([]clause, error) {
+	clauses := []clause{{}}
+	for _, e := range operands {
+		operand, err := conjunctiveForm(e, negated)
+		if err != nil {
+			return nil, err
+		}
+		if len(clauses)*len(operand) > maxClauses {
+			return nil, errTooComplex
+		}
+		var combined []clause
+		for _, c := range clauses {
+			for _, other := range operand {
+				combined = append(combined, c.merge(other))
+			}
+		}
+		clauses = combined
+	}
+	return clauses, nil
+}
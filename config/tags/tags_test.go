@@ -0,0 +1,163 @@
+package tags
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		expression string
+		want       string // Result of String
+		godog      string
+	}{
+		{"", "", ""},
+		{"k-iam", "@k-iam", "@k-iam"},
+		{"@a and not @b", "@a and not @b", "@a && ~@b"},
+		{"@a or @b and @c", "@a or (@b and @c)", "@a,@b && @a,@c"},
+		{"(@a or @b) and @c", "(@a or @b) and @c", "@a,@b && @c"},
+		{"not (@a or @b)", "not (@a or @b)", "~@a && ~@b"},
+		{"not (@a and @b)", "not (@a and @b)", "~@a,~@b"},
+		{"not not @a", "not not @a", "@a"},
+		{"(@a and @b) or (@a and @c)", "(@a and @b) or (@a and @c)", "@a && @a,@c && @b,@a && @b,@c"},
+		{" ( @a )or @b ", "@a or @b", "@a,@b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			e, err := Parse(tt.expression)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if e != nil && e.String() != tt.want {
+				t.Errorf("String() = %q, want %q", e.String(), tt.want)
+			}
+			if got, err := Godog(e); err != nil || got != tt.godog {
+				t.Errorf("Godog() = %q, %v, want %q", got, err, tt.godog)
+			}
+		})
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		expression string
+		column     int
+		message    string
+	}{
+		{"@a and", 7, "expected a tag after 'and'"},
+		{"and @a", 1, "expected a tag but found 'and'"},
+		{"(@a or @b", 1, "missing ')'"},
+		{"(@a @b)", 5, "expected ')' before '@b'"},
+		{"@a)", 3, "unexpected ')'"},
+		{"@a @b", 4, "expected 'and' or 'or' before '@b'"},
+		{"~@a", 1, "use 'not @a' instead"},
+		{"@a,@b", 1, "may not contain ','"},
+		{"@", 1, "must be followed by a tag name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			_, err := Parse(tt.expression)
+			se, ok := err.(*SyntaxError)
+			if !ok {
+				t.Fatalf("Expected a SyntaxError, got %v", err)
+			}
+			if se.Column != tt.column || !strings.Contains(se.Message, tt.message) {
+				t.Errorf("Got %q at column %d, want %q at column %d", se.Message, se.Column, tt.message, tt.column)
+			}
+		})
+	}
+}
+
+func TestGodog_TooComplex(t *testing.T) {
+	// Each 'or' of two 'and' groups doubles the number of godog groups, so eleven exceed the limit
+	var groups []string
+	for i := 0; i < 11; i++ {
+		groups = append(groups, fmt.Sprintf("(@a%d and @b%d)", i, i))
+	}
+	expression := strings.Join(groups, " or ")
+	if _, err := Parse(expression); !isTooComplex(err) {
+		t.Errorf("Expected Parse() to return a SyntaxError for a complex expression, got %v", err)
+	}
+	if _, err := Parse(strings.Join(groups[:10], " or ")); err != nil {
+		t.Errorf("Parse() error = %v", err)
+	}
+
+	var operands []Expr
+	for i := 0; i < 11; i++ {
+		operands = append(operands, And(Tag(fmt.Sprintf("a%d", i)), Tag(fmt.Sprintf("b%d", i))))
+	}
+	if _, err := Godog(Or(operands...)); !isTooComplex(err) {
+		t.Errorf("Expected Godog() to return a SyntaxError for a complex expression, got %v", err)
+	}
+}
+
+func isTooComplex(err error) bool {
+	se, ok := err.(*SyntaxError)
+	return ok && strings.Contains(se.Message, "too complex")
+}
+
+func TestMatch(t *testing.T) {
+	e, _ := Parse("(@k-iam or @k-gen) and not (@wip or @probes/kubernetes/iam/k-iam-001)")
+	tests := []struct {
+		tags []string
+		want bool
+	}{
+		{[]string{"@k-iam"}, true},
+		{[]string{"k-gen", "other"}, true},
+		{[]string{"@k-iam", "@wip"}, false},
+		{[]string{"@k-iam", "@probes/kubernetes/iam/k-iam-001"}, false},
+		{[]string{"@other"}, false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if got := Match(e, tt.tags); got != tt.want {
+			t.Errorf("Match(%v) = %v, want %v", tt.tags, got, tt.want)
+		}
+		// The godog form must select the same scenarios
+		filter, _ := Godog(e)
+		if got := matchGodog(filter, tt.tags); got != tt.want {
+			t.Errorf("Godog form matched %v = %v, want %v", tt.tags, got, tt.want)
+		}
+	}
+	if !Match(nil, nil) {
+		t.Errorf("A nil expression should match every scenario")
+	}
+}
+
+func TestNone(t *testing.T) {
+	for _, scenarioTags := range [][]string{nil, {"@a"}, {"@" + noneTag}} {
+		if Match(None(), scenarioTags) {
+			t.Errorf("None() matched %v", scenarioTags)
+		}
+	}
+	if e, err := Parse(None().String()); err != nil || e.String() != None().String() {
+		t.Errorf("Expected None() to be written in a form that Parse accepts, got %v, %v", e, err)
+	}
+}
+
+func TestBuilders(t *testing.T) {
+	e := And(Or(Tag("a"), nil, Tag("@b")), Not(nil), Not(Or(Tag("c"))))
+	if e.String() != "(@a or @b) and not @c" {
+		t.Errorf("Unexpected expression: %s", e)
+	}
+	if And() != nil || Or(nil) != nil {
+		t.Errorf("Builders without operands should return nil")
+	}
+}
+
+// matchGodog evaluates a filter in the same way as godog v0.11
+func matchGodog(filter string, tags []string) bool {
+	ok := true
+	for _, andTags := range strings.Split(filter, "&&") {
+		var okComma bool
+		for _, tag := range strings.Split(andTags, ",") {
+			tag = strings.Replace(strings.TrimSpace(tag), "@", "", -1)
+			negated := strings.HasPrefix(tag, "~")
+			found := Tag(strings.TrimPrefix(tag, "~")).Evaluate(tags)
+			okComma = okComma || found != negated
+		}
+		ok = ok && okComma
+	}
+	return ok
+}
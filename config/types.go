@@ -11,17 +11,65 @@ type CloudProviders struct {
 	Azure ac.Azure `yaml:"Azure"`
 }
 
+// SinkOpts configures a destination for run summaries and per-probe audits
+type SinkOpts struct {
+	Type       string            `yaml:"Type" validate:"required,oneof=file|stdout|http|objectstore" doc:"Where to send output: file, stdout, http or objectstore"`
+	Path       string            `yaml:"Path" doc:"Directory for file sinks, defaults to WriteDirectory"`
+	URL        string            `yaml:"URL" validate:"url" doc:"Endpoint for http and objectstore sinks; objectstore requests are not signed, so use a SAS token or another static credential"`
+	Bucket     string            `yaml:"Bucket" doc:"Bucket or container for objectstore sinks"`
+	Prefix     string            `yaml:"Prefix" doc:"Prefix for objects written by objectstore sinks"`
+	Headers    map[string]string `yaml:"Headers" secret:"true" doc:"Headers sent with each request, such as Authorization"`
+	Retries    int               `yaml:"Retries" doc:"Defaults to 3, negative values disable retries"`
+	RetryDelay string            `yaml:"RetryDelay" doc:"Duration such as 2s, doubled after each retry"`
+	Timeout    string            `yaml:"Timeout" doc:"Duration such as 10s allowed for each request, defaults to 30s"`
+}
+
+// LogFileOpts configures a file that logs are written to in addition to stderr, rotated as it grows
+type LogFileOpts struct {
+	Path         string        `yaml:"Path" env:"PROBR_LOG_FILE" doc:"If set, logs are also written to this file"`
+	MaxSize      int           `yaml:"MaxSize" env:"PROBR_LOG_FILE_MAX_SIZE" default:"100" doc:"Size in megabytes at which the file is rotated"`
+	MaxAge       time.Duration `yaml:"MaxAge" env:"PROBR_LOG_FILE_MAX_AGE" doc:"Duration such as 24h after which the file is rotated, regardless of size"`
+	MaxBackups   int           `yaml:"MaxBackups" env:"PROBR_LOG_FILE_MAX_BACKUPS" doc:"Number of rotated files to keep; if unset, all are kept"`
+	MaxBackupAge time.Duration `yaml:"MaxBackupAge" env:"PROBR_LOG_FILE_MAX_BACKUP_AGE" doc:"Duration such as 168h after which rotated files are removed; if unset, they are kept"`
+	Compress     bool          `yaml:"Compress" env:"PROBR_LOG_FILE_COMPRESS" doc:"Compress rotated files with gzip"`
+}
+
+// NotificationOpts configures a webhook to be notified when a run is complete
+type NotificationOpts struct {
+	URL             string            `yaml:"URL" validate:"required,url" doc:"Webhook to POST to when a run is complete"`
+	Headers         map[string]string `yaml:"Headers" secret:"true" doc:"Headers sent with each request, such as Authorization"`
+	Secret          string            `yaml:"Secret" secret:"true" doc:"HMAC-SHA256 key used to sign the payload"`
+	Template        string            `yaml:"Template" doc:"generic, slack, teams or a custom text/template"`
+	OnlyNewFailures bool              `yaml:"OnlyNewFailures" doc:"Only notify when a probe fails that passed in the previous run"`
+	PreviousSummary string            `yaml:"PreviousSummary" doc:"Defaults to WriteDirectory/summary.json"`
+	Retries         int               `yaml:"Retries" doc:"Defaults to 3, negative values disable retries"`
+	RetryDelay      string            `yaml:"RetryDelay" doc:"Duration such as 2s, doubled after each retry"`
+	Timeout         string            `yaml:"Timeout" doc:"Duration such as 10s allowed for each request, defaults to 30s"`
+}
+
 // GlobalOpts provides configurable options that will be used throughout the SDK
 type GlobalOpts struct {
 	StartTime          time.Time
-	VarsFile           string
-	InstallDir         string         `yaml:"InstallDir"`
-	TmpDir             string         `yaml:"TmpDir"`
-	GodogResultsFormat string         `yaml:"GodogResultsFormat"`
-	CloudProviders     CloudProviders `yaml:"CloudProviders"`
-	WriteDirectory     string         `yaml:"WriteDirectory"`
-	LogLevel           string         `yaml:"LogLevel"`
-	TagExclusions      []string       `yaml:"TagExclusions"`
-	TagInclusions      []string       `yaml:"TagInclusions"`
-	WriteConfig        string         `yaml:"WriteConfig"`
+	VarsFile           string             `validate:"path"`
+	VarsFiles          []string           `yaml:"-"` // Additional vars files, merged in order after VarsFile
+	Profile            string             `yaml:"-"` // Name of a profile within the vars files to apply after the files
+	InstallDir         string             `yaml:"InstallDir" env:"PROBR_INSTALL_DIR" doc:"Defaults to ~/probr"`
+	TmpDir             string             `yaml:"TmpDir" env:"PROBR_TMP_DIR" doc:"Defaults to InstallDir/tmp"`
+	GodogResultsFormat string             `yaml:"GodogResultsFormat" env:"PROBR_RESULTS_FORMAT" default:"cucumber" validate:"oneof=cucumber|events|junit|pretty|progress"`
+	CloudProviders     CloudProviders     `yaml:"CloudProviders" validate:"-"` // Validated by each provider, see azure.RegisterValidation
+	WriteDirectory     string             `yaml:"WriteDirectory" env:"PROBR_WRITE_DIRECTORY" doc:"Defaults to InstallDir/output"`
+	LogLevel           string             `yaml:"LogLevel" env:"PROBR_LOG_LEVEL" default:"DEBUG" validate:"oneof=TRACE|DEBUG|INFO|WARN|ERROR|OFF"`
+	LogFormat          string             `yaml:"LogFormat" env:"PROBR_LOG_FORMAT" default:"text" validate:"oneof=text|json" doc:"Use json for structured logs, including the run, pack, probe, scenario and step"`
+	LogFile            LogFileOpts        `yaml:"LogFile"`
+	TagExclusions      []string           `yaml:"TagExclusions" env:"PROBR_TAG_EXCLUSIONS" doc:"Scenarios with any of these tags are skipped"`
+	TagInclusions      []string           `yaml:"TagInclusions" env:"PROBR_TAG_INCLUSIONS" doc:"If set, only scenarios with one of these tags are run"`
+	WriteConfig        bool               `yaml:"WriteConfig" env:"PROBR_WRITE_CONFIG" doc:"Write the effective config to WriteDirectory/config.yml, with secrets redacted"`
+	ExitSeverity       string             `yaml:"ExitSeverity" env:"PROBR_EXIT_SEVERITY" validate:"oneof=none|low|medium|high|critical" doc:"Minimum severity of a failed scenario that results in a non-zero exit code; empty means any failure"`
+	Sinks              []SinkOpts         `yaml:"Sinks" doc:"If empty, output is written to WriteDirectory"`
+	Notifications      []NotificationOpts `yaml:"Notifications"`
+	provenance         map[string]string
+	flags              map[string]string
+	overrides          map[string]interface{} // Values set on the struct before Init, reapplied when the config is reloaded
+	secrets            map[string]bool        // Paths of values that were resolved from secret references
+	sections           map[string]interface{} // Config structs registered by providers and service packs, by key
 }
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/probr/probr-sdk/config/validator"
+)
+
+// Validation is a check that cannot be declared via 'validate' struct tags, such as one belonging to a service pack
+type Validation func(ctx *GlobalOpts) error
+
+var (
+	validationsMux sync.RWMutex
+	validations    = make(map[string]Validation)
+)
+
+// RegisterValidation adds a check to be run by Validate. Service packs should register their checks before calling Init.
+// Registering an existing name replaces that check.
+func RegisterValidation(name string, v Validation) {
+	validationsMux.Lock()
+	defer validationsMux.Unlock()
+	validations[name] = v
+}
+
+// Validate checks the 'validate' struct tags throughout the config and the tag expressions, then each registered section and validation,
+// and returns every problem found as validator.Errors
+func (ctx *GlobalOpts) Validate() error {
+	var problems validator.Errors
+	problems = appendProblems(problems, "", validator.Validate(ctx))
+	problems = append(problems, ctx.validateSections()...)
+	_, tagErr := TagExpression(ctx.TagInclusions, ctx.TagExclusions)
+	problems = appendProblems(problems, "", tagErr)
+
+	validationsMux.RLock()
+	defer validationsMux.RUnlock()
+	var names []string
+	for name := range validations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		problems = appendProblems(problems, name, validations[name](ctx))
+	}
+	return problems.Err()
+}
+
+// appendProblems flattens validator.Errors, or wraps any other error under the name of the validation that returned it
+func appendProblems(problems validator.Errors, name string, err error) validator.Errors {
+	if err == nil {
+		return problems
+	}
+	if errs, ok := err.(validator.Errors); ok {
+		return append(problems, errs...)
+	}
+	return append(problems, validator.FieldError{Field: name, Rule: name, Message: err.Error()})
+}
@@ -0,0 +1,191 @@
+// Package validator checks config structs against rules declared in 'validate' struct tags,
+// such as `validate:"required,uuid"`, and reports every problem at once.
+package validator
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Check reports whether a value satisfies a rule. Param is the text following '=' in the tag, if any.
+type Check func(value reflect.Value, param string) error
+
+// FieldError describes a single value that failed a rule
+type FieldError struct {
+	Field   string // Dotted YAML path, such as "CloudProviders.Azure.TenantID"
+	Rule    string
+	Message string
+	EnvVar  string // The env var that may be used to set the value, if any
+}
+
+func (e FieldError) Error() string {
+	if e.EnvVar != "" {
+		return fmt.Sprintf("%s %s (set it in a vars file or via %s)", e.Field, e.Message, e.EnvVar)
+	}
+	return fmt.Sprintf("%s %s", e.Field, e.Message)
+}
+
+// Errors contains every problem found during validation
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	problems := make([]string, len(e))
+	for i, fe := range e {
+		problems[i] = "  - " + fe.Error()
+	}
+	return fmt.Sprintf("%d config problem(s) found:\n%s", len(e), strings.Join(problems, "\n"))
+}
+
+// Err returns nil if there are no problems, so that an empty Errors is never returned as a non-nil error
+func (e Errors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+var (
+	rulesMux sync.RWMutex
+	rules    = map[string]Check{
+		"required": checkRequired,
+		"oneof":    checkOneOf,
+		"path":     checkPath,
+		"url":      checkURL,
+		"uuid":     checkUUID,
+	}
+)
+
+// RegisterRule makes a custom rule available to 'validate' tags, allowing service packs to declare their own checks.
+// Registering an existing name replaces that rule.
+func RegisterRule(name string, check Check) {
+	rulesMux.Lock()
+	defer rulesMux.Unlock()
+	rules[name] = check
+}
+
+// Validate walks the struct that ptr points to, including nested structs and slices of structs,
+// and checks each field against the rules in its 'validate' tag. Rules are comma separated.
+// All rules other than 'required' are skipped for empty values.
+func Validate(ptr interface{}) error {
+	v := reflect.ValueOf(ptr)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return Errors{{Field: fmt.Sprintf("%T", ptr), Rule: "struct", Message: "is not a struct and cannot be validated"}}
+	}
+	var problems Errors
+	validateStruct("", v, &problems)
+	return problems.Err()
+}
+
+func validateStruct(prefix string, v reflect.Value, problems *Errors) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("validate")
+		if f.PkgPath != "" || tag == "-" { // unexported or explicitly skipped
+			continue
+		}
+		path := prefix + fieldName(f)
+		field := v.Field(i)
+		for _, rule := range splitRules(tag) {
+			if err := check(rule, field); err != nil {
+				*problems = append(*problems, FieldError{Field: path, Rule: rule, Message: err.Error(), EnvVar: f.Tag.Get("env")})
+			}
+		}
+		switch field.Kind() {
+		case reflect.Struct:
+			validateStruct(path+".", field, problems)
+		case reflect.Slice:
+			if field.Type().Elem().Kind() == reflect.Struct {
+				for j := 0; j < field.Len(); j++ {
+					validateStruct(fmt.Sprintf("%s[%d].", path, j), field.Index(j), problems)
+				}
+			}
+		}
+	}
+}
+
+func check(rule string, value reflect.Value) error {
+	name, param := rule, ""
+	if i := strings.Index(rule, "="); i >= 0 {
+		name, param = rule[:i], rule[i+1:]
+	}
+	rulesMux.RLock()
+	c, ok := rules[name]
+	rulesMux.RUnlock()
+	if !ok {
+		return fmt.Errorf("declares unknown validation rule '%s'", name)
+	}
+	if name != "required" && value.IsZero() {
+		return nil
+	}
+	return c(value, param)
+}
+
+func splitRules(tag string) (rules []string) {
+	for _, rule := range strings.Split(tag, ",") {
+		if rule = strings.TrimSpace(rule); rule != "" {
+			rules = append(rules, rule)
+		}
+	}
+	return
+}
+
+// fieldName prefers the YAML name, so that problems refer to the keys a user would write in a vars file
+func fieldName(f reflect.StructField) string {
+	if name := strings.Split(f.Tag.Get("yaml"), ",")[0]; name != "" && name != "-" {
+		return name
+	}
+	return f.Name
+}
+
+func checkRequired(value reflect.Value, _ string) error {
+	if value.IsZero() {
+		return fmt.Errorf("is required")
+	}
+	return nil
+}
+
+// checkOneOf compares strings case-insensitively against options separated by '|', such as "oneof=DEBUG|INFO"
+func checkOneOf(value reflect.Value, options string) error {
+	if value.Kind() != reflect.String {
+		return fmt.Errorf("must be a string to use the oneof rule")
+	}
+	for _, option := range strings.Split(options, "|") {
+		if strings.EqualFold(value.String(), option) {
+			return nil
+		}
+	}
+	return fmt.Errorf("must be one of %s but found '%s'", strings.Join(strings.Split(options, "|"), ", "), value.String())
+}
+
+func checkPath(value reflect.Value, _ string) error {
+	if _, err := os.Stat(value.String()); err != nil {
+		return fmt.Errorf("must be an existing path but '%s' could not be found", value.String())
+	}
+	return nil
+}
+
+func checkURL(value reflect.Value, _ string) error {
+	u, err := url.Parse(value.String())
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("must be an absolute URL such as https://example.com but found '%s'", value.String())
+	}
+	return nil
+}
+
+func checkUUID(value reflect.Value, _ string) error {
+	if !uuidPattern.MatchString(value.String()) {
+		return fmt.Errorf("must be a UUID such as 00000000-0000-0000-0000-000000000000 but found '%s'", value.String())
+	}
+	return nil
+}
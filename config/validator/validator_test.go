@@ -0,0 +1,86 @@
+package validator
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type sink struct {
+	Type string `yaml:"Type" validate:"required,oneof=file|http"`
+	URL  string `yaml:"URL" validate:"url"`
+}
+
+type opts struct {
+	TenantID string `yaml:"TenantID" env:"PROBR_TENANT_ID" validate:"required,uuid"`
+	Level    string `yaml:"Level" validate:"oneof=DEBUG|INFO"`
+	Path     string `validate:"path"`
+	Sinks    []sink `yaml:"Sinks"`
+	Skipped  sink   `validate:"-"`
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     opts
+		expected []string
+	}{
+		{
+			name:     "Test that a valid struct has no problems",
+			opts:     opts{TenantID: "0b2ac2ca-8f0f-4d9b-92c6-2a4bb3c1d1a1", Level: "info", Path: os.TempDir(), Sinks: []sink{{Type: "http", URL: "https://example.com"}}},
+			expected: nil,
+		},
+		{
+			name:     "Test that every problem is reported at once",
+			opts:     opts{Level: "LOUD", Path: "/no/such/path", Sinks: []sink{{Type: "http", URL: "example.com"}, {}}},
+			expected: []string{"TenantID", "Level", "Path", "Sinks[0].URL", "Sinks[1].Type"},
+		},
+		{
+			name:     "Test that formats are checked",
+			opts:     opts{TenantID: "not-a-uuid"},
+			expected: []string{"TenantID"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(&tt.opts)
+			var fields []string
+			if err != nil {
+				for _, fe := range err.(Errors) {
+					fields = append(fields, fe.Field)
+				}
+			}
+			if !reflect.DeepEqual(fields, tt.expected) {
+				t.Errorf("Validate() problems = %v, expected %v; err: %v", fields, tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestValidate_EnvVarHint(t *testing.T) {
+	err := Validate(&opts{Level: "INFO"})
+	if err == nil || !strings.Contains(err.Error(), "PROBR_TENANT_ID") {
+		t.Errorf("Expected the error to suggest PROBR_TENANT_ID, got: %v", err)
+	}
+}
+
+func TestRegisterRule(t *testing.T) {
+	RegisterRule("even", func(value reflect.Value, _ string) error {
+		if value.Int()%2 != 0 {
+			return fmt.Errorf("must be even")
+		}
+		return nil
+	})
+	custom := struct {
+		Count   int `validate:"even"`
+		Unknown int `validate:"nonexistent"`
+	}{Count: 3}
+
+	err := Validate(&custom)
+	errs, ok := err.(Errors)
+	if !ok || len(errs) != 2 || errs[0].Rule != "even" || errs[1].Rule != "nonexistent" {
+		t.Errorf("Expected custom and unknown rule problems, got: %v", err)
+	}
+}
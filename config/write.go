@@ -0,0 +1,107 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Redacted replaces secret values in the effective config
+const Redacted = "<redacted>"
+
+// EffectiveConfig returns the config as YAML after all layers, defaults and secret references have been applied.
+// Values tagged `secret:"true"` and values resolved from secret references are redacted.
+func (ctx *GlobalOpts) EffectiveConfig() ([]byte, error) {
+	data, err := yaml.Marshal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var effective yaml.MapSlice
+	if err = yaml.Unmarshal(data, &effective); err != nil {
+		return nil, err
+	}
+	if effective, err = ctx.withSections(effective); err != nil {
+		return nil, err
+	}
+	redact := ctx.redactedPaths()
+	return yaml.Marshal(redactValue("", effective, redact))
+}
+
+// WriteEffectiveConfig writes the effective config to WriteDirectory/config.yml and returns its path
+func (ctx *GlobalOpts) WriteEffectiveConfig() (path string, err error) {
+	data, err := ctx.EffectiveConfig()
+	if err != nil {
+		return
+	}
+	if err = os.MkdirAll(ctx.WriteDirectory, 0755); err != nil {
+		return
+	}
+	path = filepath.Join(ctx.WriteDirectory, "config.yml")
+	if err = ioutil.WriteFile(path, data, 0600); err != nil {
+		return
+	}
+	log.Printf("[INFO] Effective config written to %s", path)
+	return
+}
+
+// redactedPaths lists each value that is tagged as a secret or was resolved from a secret reference
+func (ctx *GlobalOpts) redactedPaths() map[string]bool {
+	redact := make(map[string]bool)
+	for path := range ctx.secrets {
+		redact[path] = true
+	}
+	collectSecretPaths("", reflect.ValueOf(ctx).Elem(), false, redact)
+	for _, key := range ctx.sectionKeys() {
+		collectSecretPaths(key, reflect.ValueOf(ctx.sections[key]).Elem(), false, redact)
+	}
+	return redact
+}
+
+func collectSecretPaths(path string, v reflect.Value, isSecret bool, redact map[string]bool) {
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath == "" {
+				collectSecretPaths(joinPath(path, fieldPath(f)), v.Field(i), f.Tag.Get("secret") == "true", redact)
+			}
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			collectSecretPaths(fmt.Sprintf("%s[%d]", path, i), v.Index(i), isSecret, redact)
+		}
+	case reflect.Map:
+		for _, key := range v.MapKeys() {
+			collectSecretPaths(fmt.Sprintf("%s.%v", path, key), v.MapIndex(key), isSecret, redact)
+		}
+	default:
+		if isSecret && !v.IsZero() {
+			redact[path] = true
+		}
+	}
+}
+
+// redactValue walks the generic YAML using the same paths as collectSecretPaths
+func redactValue(path string, value interface{}, redact map[string]bool) interface{} {
+	switch v := value.(type) {
+	case yaml.MapSlice:
+		for i, item := range v {
+			v[i].Value = redactValue(joinPath(path, fmt.Sprint(item.Key)), item.Value, redact)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactValue(fmt.Sprintf("%s[%d]", path, i), item, redact)
+		}
+	default:
+		if redact[path] {
+			return Redacted
+		}
+	}
+	return value
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGlobalOpts_WriteEffectiveConfig(t *testing.T) {
+	os.Setenv("PROBR_TEST_TOKEN", "token-from-env")
+	defer os.Unsetenv("PROBR_TEST_TOKEN")
+	varsFile := writeVarsFile(t, "vars.yml", `
+WriteConfig: true
+LogLevel: INFO
+CloudProviders:
+  Azure:
+    TenantID: plain-tenant
+    ClientSecret: plaintext-secret
+Sinks:
+  - Type: http
+    URL: https://example.com/results
+    Headers:
+      Authorization: plaintext-header
+      X-Token: env:PROBR_TEST_TOKEN
+`)
+	ctx := GlobalOpts{VarsFile: varsFile, WriteDirectory: t.TempDir()}
+	if err := ctx.Init(); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(ctx.WriteDirectory, "config.yml"))
+	if err != nil {
+		t.Fatalf("Expected config.yml to be written: %v", err)
+	}
+	written := string(data)
+	for _, secret := range []string{"plaintext-secret", "plaintext-header", "token-from-env"} {
+		if strings.Contains(written, secret) {
+			t.Errorf("Effective config contains the secret '%s':\n%s", secret, written)
+		}
+	}
+	for _, expected := range []string{"LogLevel: INFO", "TenantID: plain-tenant", "ClientSecret: <redacted>", "Authorization: <redacted>", "X-Token: <redacted>"} {
+		if !strings.Contains(written, expected) {
+			t.Errorf("Effective config does not contain '%s':\n%s", expected, written)
+		}
+	}
+	if ctx.CloudProviders.Azure.ClientSecret != "plaintext-secret" {
+		t.Errorf("Redaction should not modify the config itself")
+	}
+}
+
+func TestGlobalOpts_SetDefaults(t *testing.T) {
+	dir := t.TempDir()
+	for name, value := range map[string]string{
+		"PROBR_WRITE_CONFIG":        "true",
+		"PROBR_WRITE_DIRECTORY":     dir,
+		"PROBR_AZURE_CLIENT_SECRET": "env:PROBR_TEST_TOKEN",
+		"PROBR_TEST_TOKEN":          "token-from-env",
+	} {
+		os.Setenv(name, value)
+		defer os.Unsetenv(name)
+	}
+
+	ctx := GlobalOpts{}
+	ctx.setDefaults()
+	if ctx.LogLevel != "DEBUG" || ctx.WriteDirectory != dir {
+		t.Errorf("Defaults and env vars were not applied: LogLevel = %s, WriteDirectory = %s", ctx.LogLevel, ctx.WriteDirectory)
+	}
+	if ctx.CloudProviders.Azure.ClientSecret != "env:PROBR_TEST_TOKEN" {
+		t.Errorf("Secret references should only be resolved by Init, got: %s", ctx.CloudProviders.Azure.ClientSecret)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "config.yml")); !os.IsNotExist(err) {
+		t.Errorf("config.yml should only be written by Init, got: %v", err)
+	}
+}
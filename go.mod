@@ -10,13 +10,17 @@ require (
 	github.com/Azure/go-autorest/autorest/to v0.4.0
 	github.com/Azure/go-autorest/autorest/validation v0.3.1 // indirect
 	github.com/briandowns/spinner v1.12.0
+	github.com/cucumber/gherkin-go/v11 v11.0.0
 	github.com/cucumber/godog v0.11.0
 	github.com/cucumber/messages-go/v10 v10.0.3
+	github.com/golang/protobuf v1.4.3
 	github.com/hashicorp/go-hclog v0.14.1
 	github.com/hashicorp/go-plugin v1.4.0
 	github.com/hashicorp/logutils v1.0.0
 	github.com/markbates/pkger v0.17.1
 	github.com/open-policy-agent/opa v0.27.1
+	google.golang.org/grpc v1.27.1
+	google.golang.org/protobuf v1.24.0
 	gopkg.in/yaml.v2 v2.4.0
 	k8s.io/api v0.19.6
 	k8s.io/apimachinery v0.19.6
@@ -0,0 +1,126 @@
+package logging
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	hclog "github.com/hashicorp/go-hclog"
+)
+
+// EventType identifies the kind of progress reported by an Event
+type EventType string
+
+// Types of Event published during a run
+const (
+	ProbeStarted     EventType = "probe_started"
+	ProbeFinished    EventType = "probe_finished"
+	ScenarioStarted  EventType = "scenario_started"
+	ScenarioFinished EventType = "scenario_finished"
+	StepFinished     EventType = "step_finished"
+	LogLine          EventType = "log"
+)
+
+// Results reported by the *Finished events
+const (
+	ResultPassed    = "Passed"
+	ResultFailed    = "Failed"
+	ResultSkipped   = "Skipped"   // Reported for steps that were not run because an earlier step failed
+	ResultUndefined = "Undefined" // Reported for steps without a matching step definition
+)
+
+// Event reports progress while probes run, such as the result of a step, or a line that was logged.
+// Fields identify the probe, scenario and step that the event relates to.
+type Event struct {
+	Type EventType
+	Time time.Time
+	Fields
+	Result  string // Set for *Finished events
+	Error   string // Set for *Finished events that failed with an error
+	Level   string // Set for LogLine events
+	Message string // Set for LogLine events
+}
+
+var (
+	handlersMux sync.RWMutex
+	handlers    = make(map[int]func(Event))
+	nextHandler int
+)
+
+// Publish passes the event to each subscribed handler, setting its Time if it is not already set.
+// The probe engine publishes events as each probe, scenario and step runs.
+func Publish(e Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	handlersMux.RLock()
+	current := make([]func(Event), 0, len(handlers))
+	for _, handle := range handlers {
+		current = append(current, handle)
+	}
+	handlersMux.RUnlock()
+	for _, handle := range current {
+		handle(e)
+	}
+}
+
+// Subscribe passes published events to handle until unsubscribe is called. If logLevel is set, lines logged
+// at or above it via the active logger are also passed to handle as LogLine events.
+// Handlers must not log or block, as lines are passed to them while the logger is locked.
+func Subscribe(handle func(Event), logLevel string) (unsubscribe func()) {
+	handlersMux.Lock()
+	id := nextHandler
+	nextHandler++
+	handlers[id] = handle
+	handlersMux.Unlock()
+
+	stopLogs := func() {}
+	if logger, ok := activeLogger.(hclog.InterceptLogger); ok && logLevel != "" {
+		sink := &eventSink{level: hclog.LevelFromString(logLevel), handle: handle}
+		logger.RegisterSink(sink)
+		stopLogs = func() { logger.DeregisterSink(sink) }
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			handlersMux.Lock()
+			delete(handlers, id)
+			handlersMux.Unlock()
+			stopLogs()
+		})
+	}
+}
+
+// eventSink is an hclog.SinkAdapter that passes log lines to an event handler
+type eventSink struct {
+	level  hclog.Level
+	handle func(Event)
+}
+
+// Accept implements hclog.SinkAdapter, taking Fields from the line's args and appending any others to the message
+func (s *eventSink) Accept(name string, level hclog.Level, msg string, args ...interface{}) {
+	if level < s.level {
+		return
+	}
+	e := Event{Type: LogLine, Time: time.Now(), Level: strings.ToUpper(level.String()), Message: msg}
+	for i := 0; i+1 < len(args); i += 2 {
+		value := fmt.Sprint(args[i+1])
+		switch args[i] {
+		case RunIDKey:
+			e.RunID = value
+		case PackKey:
+			e.Pack = value
+		case ProbeKey:
+			e.Probe = value
+		case ScenarioKey:
+			e.Scenario = value
+		case StepKey:
+			e.Step = value
+		default:
+			e.Message += fmt.Sprintf(" %v=%s", args[i], value)
+		}
+	}
+	s.handle(e)
+}
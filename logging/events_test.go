@@ -0,0 +1,54 @@
+package logging
+
+import (
+	"io/ioutil"
+	"log"
+	"testing"
+)
+
+func TestSubscribe(t *testing.T) {
+	defer UseLogger("default")
+	defer SetFields(SetFields(Fields{RunID: "run", Probe: "probe"}))
+	UseLogger("events-test", "DEBUG", ioutil.Discard, false)
+
+	var events []Event
+	unsubscribe := Subscribe(func(e Event) { events = append(events, e) }, "INFO")
+	Publish(Event{Type: StepFinished, Fields: Fields{Probe: "probe", Step: "step"}, Result: ResultPassed})
+	log.Printf("[DEBUG] below the subscribed level")
+	log.Printf("[WARN] legacy message")
+	ProbeLogger().Info("structured message", "key", "value")
+	unsubscribe()
+	Publish(Event{Type: StepFinished})
+	log.Printf("[WARN] after unsubscribing")
+
+	if len(events) != 3 {
+		t.Fatalf("Expected 3 events, got %+v", events)
+	}
+	if events[0].Type != StepFinished || events[0].Step != "step" || events[0].Time.IsZero() {
+		t.Errorf("Unexpected published event: %+v", events[0])
+	}
+	for i, expected := range []struct{ level, message string }{
+		{"WARN", "legacy message"},
+		{"INFO", "structured message key=value"},
+	} {
+		e := events[i+1]
+		if e.Type != LogLine || e.Level != expected.level || e.Message != expected.message {
+			t.Errorf("Unexpected log event: %+v", e)
+		}
+		if e.RunID != "run" || e.Probe != "probe" {
+			t.Errorf("Expected fields to be taken from the log line: %+v", e)
+		}
+	}
+}
+
+func TestSubscribe_WithoutLogs(t *testing.T) {
+	var events []Event
+	unsubscribe := Subscribe(func(e Event) { events = append(events, e) }, "")
+	defer unsubscribe()
+	log.Printf("[ERROR] not an event")
+	Publish(Event{Type: ProbeStarted})
+
+	if len(events) != 1 || events[0].Type != ProbeStarted {
+		t.Errorf("Expected only the published event, got %+v", events)
+	}
+}
@@ -0,0 +1,103 @@
+package logging
+
+import (
+	"crypto/rand"
+	"fmt"
+	"sync"
+
+	hclog "github.com/hashicorp/go-hclog"
+)
+
+// Keys used for each of the Fields in structured logs
+const (
+	RunIDKey    = "run_id"
+	PackKey     = "pack"
+	ProbeKey    = "probe"
+	ScenarioKey = "scenario"
+	StepKey     = "step"
+)
+
+// Fields identify the work that is being logged, and are attached to log lines as key/value pairs.
+// Empty fields are omitted.
+type Fields struct {
+	RunID    string
+	Pack     string
+	Probe    string
+	Scenario string
+	Step     string
+}
+
+// Args returns the non-empty fields as alternating keys and values, as expected by hclog
+func (f Fields) Args() (args []interface{}) {
+	for _, field := range []struct{ key, value string }{
+		{RunIDKey, f.RunID},
+		{PackKey, f.Pack},
+		{ProbeKey, f.Probe},
+		{ScenarioKey, f.Scenario},
+		{StepKey, f.Step},
+	} {
+		if field.value != "" {
+			args = append(args, field.key, field.value)
+		}
+	}
+	return
+}
+
+var (
+	fieldsMux     sync.RWMutex
+	currentFields Fields
+)
+
+// SetFields replaces the fields attached to log.Printf statements and to ProbeLogger, and returns the previous
+// fields so that they may be restored. The probe engine sets these as each probe, scenario and step runs.
+// As they apply to the whole process, they are not suitable for probes that run concurrently; probe stores with their
+// own logger keep their fields separately, see probeengine.ProbeStore.ProbeLogger.
+func SetFields(f Fields) (previous Fields) {
+	fieldsMux.Lock()
+	defer fieldsMux.Unlock()
+	previous, currentFields = currentFields, f
+	return
+}
+
+// CurrentFields returns the fields set by the probe engine for the work currently being run
+func CurrentFields() Fields {
+	fieldsMux.RLock()
+	defer fieldsMux.RUnlock()
+	return currentFields
+}
+
+// WithFields returns a logger that attaches the non-empty fields to each log line
+func WithFields(logger hclog.Logger, f Fields) hclog.Logger {
+	if args := f.Args(); len(args) > 0 {
+		return logger.With(args...)
+	}
+	return logger
+}
+
+// ProbeLogger returns the active logger with the current fields attached, for structured logs such as
+// ProbeLogger().Info("pod created", "name", podName)
+func ProbeLogger() hclog.Logger {
+	return WithFields(activeLogger, CurrentFields())
+}
+
+// NewRunID creates a random identifier for a single execution of a pack's probes
+func NewRunID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return fmt.Sprintf("%x", b)
+}
+
+// fieldsWriter passes log.Printf output to a logger with the current fields attached,
+// inferring the level from prefixes such as "[INFO]"
+type fieldsWriter struct {
+	logger hclog.Logger
+}
+
+func (w fieldsWriter) Write(p []byte) (int, error) {
+	opts := &hclog.StandardLoggerOptions{InferLevels: true}
+	logger := WithFields(w.logger, CurrentFields())
+	if intercept, ok := logger.(hclog.InterceptLogger); ok {
+		return intercept.StandardWriterIntercept(opts).Write(p) // Also send to any sinks registered via Capture
+	}
+	return logger.StandardWriter(opts).Write(p)
+}
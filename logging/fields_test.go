@@ -0,0 +1,38 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"testing"
+)
+
+func TestFields(t *testing.T) {
+	defer UseLogger("default")
+	defer SetFields(SetFields(Fields{RunID: "run", Pack: "pack", Probe: "probe", Scenario: "scenario"}))
+
+	var output bytes.Buffer
+	UseLogger("fields-test", "DEBUG", &output, true)
+	log.Printf("[WARN] legacy message")
+	ProbeLogger().Info("structured message", "key", "value")
+
+	decoder := json.NewDecoder(&output)
+	for _, expected := range []struct{ level, message string }{
+		{"warn", "legacy message"},
+		{"info", "structured message"},
+	} {
+		line := make(map[string]interface{})
+		if err := decoder.Decode(&line); err != nil {
+			t.Fatalf("Expected a JSON log line: %v", err)
+		}
+		if line["@level"] != expected.level || line["@message"] != expected.message {
+			t.Errorf("Unexpected log line: %v", line)
+		}
+		if line[RunIDKey] != "run" || line[PackKey] != "pack" || line[ProbeKey] != "probe" || line[ScenarioKey] != "scenario" {
+			t.Errorf("Expected fields to be attached: %v", line)
+		}
+		if _, ok := line[StepKey]; ok {
+			t.Errorf("Empty fields should be omitted: %v", line)
+		}
+	}
+}
@@ -3,22 +3,36 @@ package logging
 import (
 	"io"
 	"log"
-	"os"
+	"sync"
 
 	hclog "github.com/hashicorp/go-hclog"
 	"github.com/probr/probr-sdk/config"
 )
 
+// defaultLoggerName is the name of the logger that is active unless UseLogger selects another
+const defaultLoggerName = "default"
+
 var (
 	activeLogger hclog.Logger
 	loggers      map[string]hclog.Logger
 )
 
 func init() {
-	// Initialize default logger
-	name := "default"
+	// Initialize default logger. This runs before any vars file is read, so only env vars are applied until Configure is called.
 	loggers = make(map[string]hclog.Logger)
-	UseLogger(name)
+	UseLogger(defaultLoggerName)
+}
+
+// Configure rebuilds the default logger with the level, format and log file from c, and returns it.
+// It should be called once c has been initialized via Init so that values from vars files and profiles are applied;
+// sdk.Default calls it with config.GlobalConfig. If the default logger is active, log.Printf statements use the new one.
+func Configure(c *config.GlobalOpts) hclog.Logger {
+	wasActive := activeLogger == nil || activeLogger == loggers[defaultLoggerName]
+	loggers[defaultLoggerName] = NewLogger(c.LogLevel, Output(c.LogFile), c.JSONLogs())
+	if wasActive {
+		SetLogWriter(defaultLoggerName, fieldsWriter{logger: loggers[defaultLoggerName]})
+	}
+	return loggers[defaultLoggerName]
 }
 
 // Logger returns the active logger for use in
@@ -27,36 +41,69 @@ func Logger() hclog.Logger {
 	return activeLogger
 }
 
-// Writer ...
+// Writer returns a writer for the active logger, which infers the level from prefixes such as "[INFO]"
+// and attaches the current fields to each line
 func Writer() io.Writer {
-	return activeLogger.StandardWriter(&hclog.StandardLoggerOptions{InferLevels: true})
+	return fieldsWriter{logger: activeLogger}
 }
 
-// newLogger creates a new hclog.Logger instance with the given name and log level
+// newLogger creates a new hclog.Logger instance using the log level from the global config
 func newLogger(writer io.Writer, jsonFormat bool) hclog.Logger {
+	return NewLogger(config.GlobalConfig.LogLevel, writer, jsonFormat)
+}
+
+// NewLogger creates a new hclog.Logger with the given log level, without changing the loggers held by this package.
+// This allows a logger to be created for each sdk.Runtime.
+func NewLogger(level string, writer io.Writer, jsonFormat bool) hclog.Logger {
 	// For level options, reference:
 	// https://github.com/hashicorp/go-hclog/blob/master/logger.go#L19
-	return hclog.New(&hclog.LoggerOptions{
-		Level:      hclog.LevelFromString(config.GlobalConfig.LogLevel),
+	// Intercept loggers allow output to also be copied elsewhere, see Capture
+	return hclog.NewInterceptLogger(&hclog.LoggerOptions{
+		Level:      hclog.LevelFromString(level),
 		Output:     writer,
-		JSONFormat: jsonFormat, // TODO: Check env var to determine json format
+		JSONFormat: jsonFormat,
 	})
 }
 
+// Capture copies everything logged at or above the given level via the active logger, including log.Printf
+// statements, to w as text until stop is called. The probe engine uses this to write each probe's logs
+// alongside its audit, while they continue to be written to the active logger.
+func Capture(w io.Writer, level string) (stop func()) {
+	return CaptureLogger(activeLogger, w, level)
+}
+
+// CaptureLogger copies everything logged at or above the given level via logger to w, as Capture does for the
+// active logger. Loggers that were not created by this package cannot be captured, and stop has no effect.
+func CaptureLogger(logger hclog.Logger, w io.Writer, level string) (stop func()) {
+	intercept, ok := logger.(hclog.InterceptLogger)
+	if !ok {
+		return func() {}
+	}
+	sink := hclog.NewSinkAdapter(&hclog.LoggerOptions{
+		Level:  hclog.LevelFromString(level),
+		Output: w,
+	})
+	intercept.RegisterSink(sink)
+	var once sync.Once
+	return func() {
+		once.Do(func() { intercept.DeregisterSink(sink) })
+	}
+}
+
 // GetLogger returns an hc logger with the provided name
 // and creates or updates an existing logger using args
 // args[0] = Level
-// args[1] = Output
-// args[2] = JSONFormat
+// args[1] = Output; Defaults to stderr and LogFile in config.GlobalConfig when the logger is created
+// args[2] = JSONFormat; Defaults to LogFormat in config.GlobalConfig when the logger is created
+// The default logger is created before config is initialized; use Configure to apply LogFile and LogFormat to it.
 func GetLogger(name string, args ...interface{}) hclog.Logger {
 	if loggers[name] == nil {
 		if len(args) > 2 {
 			loggers[name] = newLogger(args[1].(io.Writer), args[2].(bool))
 		} else if len(args) > 1 {
-			// If json format is not specified, default to false
-			loggers[name] = newLogger(args[1].(io.Writer), false)
+			loggers[name] = newLogger(args[1].(io.Writer), config.GlobalConfig.JSONLogs())
 		} else {
-			loggers[name] = newLogger(os.Stderr, false)
+			loggers[name] = newLogger(Output(config.GlobalConfig.LogFile), config.GlobalConfig.JSONLogs())
 		}
 	}
 
@@ -71,8 +118,7 @@ func GetLogger(name string, args ...interface{}) hclog.Logger {
 // and sets it as the primary logger for the log package
 func UseLogger(name string, args ...interface{}) hclog.Logger {
 	logger := GetLogger(name, args...)
-	writer := logger.StandardWriter(&hclog.StandardLoggerOptions{InferLevels: true})
-	SetLogWriter(name, writer)
+	SetLogWriter(name, fieldsWriter{logger: logger})
 	return logger
 }
 
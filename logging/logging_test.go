@@ -0,0 +1,44 @@
+package logging
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/probr/probr-sdk/config"
+)
+
+func TestConfigure(t *testing.T) {
+	defer Configure(&config.GlobalConfig)
+
+	dir := t.TempDir()
+	logFile := filepath.Join(dir, "probr.log")
+	varsFile := filepath.Join(dir, "vars.yml")
+	vars := "LogLevel: INFO\nLogFormat: json\nLogFile:\n  Path: " + logFile + "\n"
+	if err := ioutil.WriteFile(varsFile, []byte(vars), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c := &config.GlobalOpts{VarsFile: varsFile}
+	if err := c.Init(); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+
+	if Configure(c) != Logger() {
+		t.Errorf("Expected the reconfigured default logger to be active")
+	}
+	log.Printf("[DEBUG] below the configured level")
+	log.Printf("[INFO] from the vars file")
+
+	data, err := ioutil.ReadFile(logFile)
+	if err != nil {
+		t.Fatalf("Expected logs to be written to the configured file: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	var line map[string]interface{}
+	if len(lines) != 1 || json.Unmarshal([]byte(lines[0]), &line) != nil || line["@message"] != "from the vars file" {
+		t.Errorf("Expected a single JSON line at the configured level, found: %s", data)
+	}
+}
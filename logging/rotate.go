@@ -0,0 +1,260 @@
+package logging
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/probr/probr-sdk/config"
+)
+
+// archiveTimeFormat sorts lexically in the order archives were created
+const archiveTimeFormat = "20060102T150405.000"
+
+// RotatingFile is an io.WriteCloser that appends to a file, and moves it aside as an archive once it exceeds
+// MaxSize or MaxAge. Archives are named <name>-<time><ext>, such as probr-20210102T150405.000.log,
+// and are suitable for long-running deployments where logs would otherwise grow without limit.
+type RotatingFile struct {
+	Path         string
+	MaxSize      int64         // Size in bytes at which the file is rotated; zero for no limit
+	MaxAge       time.Duration // Time since the file was started after which it is rotated; zero for no limit
+	MaxBackups   int           // Number of archives to retain, removing the oldest first; zero retains all
+	MaxBackupAge time.Duration // Time since an archive was rotated after which it is removed; zero retains all
+	Compress     bool          // Compress archives with gzip, adding a .gz extension
+
+	mux     sync.Mutex
+	file    *os.File
+	size    int64
+	started time.Time
+}
+
+var (
+	logFilesMux sync.Mutex
+	logFiles    = make(map[string]*RotatingFile)
+)
+
+// Output returns the writer used by loggers that are not given one: stderr, and the configured log file if any.
+// Loggers writing to the same path share a single RotatingFile, which takes the options of the latest call,
+// such as when the config has been reloaded.
+func Output(opts config.LogFileOpts) io.Writer {
+	if opts.Path == "" {
+		return os.Stderr
+	}
+	logFilesMux.Lock()
+	defer logFilesMux.Unlock()
+	r := logFiles[opts.Path]
+	if r == nil {
+		r = &RotatingFile{Path: opts.Path}
+		logFiles[opts.Path] = r
+	}
+	r.configure(opts)
+	return io.MultiWriter(os.Stderr, r)
+}
+
+// configure applies the rotation options, which take effect from the next write
+func (r *RotatingFile) configure(opts config.LogFileOpts) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.MaxSize = int64(opts.MaxSize) * 1024 * 1024
+	r.MaxAge = opts.MaxAge
+	r.MaxBackups = opts.MaxBackups
+	r.MaxBackupAge = opts.MaxBackupAge
+	r.Compress = opts.Compress
+}
+
+// Write appends p to the file, rotating it first if the write would exceed MaxSize or the file is older than MaxAge
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if r.file == nil {
+		if err := r.open(); err != nil {
+			return 0, err
+		}
+	}
+	if r.size > 0 && r.exceedsLimits(len(p)) {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// Rotate archives the current file, if it has any content, and starts a new one
+func (r *RotatingFile) Rotate() error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if r.file == nil {
+		if err := r.open(); err != nil {
+			return err
+		}
+	}
+	if r.size == 0 {
+		return nil
+	}
+	return r.rotate()
+}
+
+// Close closes the current file. A later Write will reopen it.
+func (r *RotatingFile) Close() error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+func (r *RotatingFile) exceedsLimits(pending int) bool {
+	if r.MaxSize > 0 && r.size+int64(pending) > r.MaxSize {
+		return true
+	}
+	return r.MaxAge > 0 && time.Since(r.started) > r.MaxAge
+}
+
+// open appends to an existing file, treating its modification time as the time it was started,
+// and removes any archives beyond MaxBackups or MaxBackupAge
+func (r *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(r.Path), 0755); err != nil {
+		return err
+	}
+	if err := r.removeOldArchives(); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(r.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	r.file, r.size, r.started = f, info.Size(), info.ModTime()
+	if r.size == 0 {
+		r.started = time.Now()
+	}
+	return nil
+}
+
+func (r *RotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return err
+	}
+	r.file = nil
+	archive := r.archivePath(time.Now())
+	for t := time.Now(); exists(archive) || exists(archive+".gz"); archive = r.archivePath(t) {
+		t = t.Add(time.Millisecond) // Avoid replacing an archive created within the same millisecond
+	}
+	if err := os.Rename(r.Path, archive); err != nil {
+		return err
+	}
+	if r.Compress {
+		if err := compress(archive); err != nil {
+			return fmt.Errorf("failed to compress %s: %v", archive, err)
+		}
+	}
+	return r.open()
+}
+
+func (r *RotatingFile) archivePath(t time.Time) string {
+	ext := filepath.Ext(r.Path)
+	return fmt.Sprintf("%s-%s%s", strings.TrimSuffix(r.Path, ext), t.Format(archiveTimeFormat), ext)
+}
+
+// archives lists the archives of this file, oldest first
+func (r *RotatingFile) archives() ([]string, error) {
+	ext := filepath.Ext(r.Path)
+	prefix := strings.TrimSuffix(r.Path, ext) + "-"
+	matches, err := filepath.Glob(prefix + "*")
+	if err != nil {
+		return nil, err
+	}
+	var archives []string
+	for _, match := range matches {
+		if _, err := r.archiveTime(match); err == nil {
+			archives = append(archives, match)
+		}
+	}
+	sort.Strings(archives)
+	return archives, nil
+}
+
+// archiveTime reads the time an archive was rotated from its name
+func (r *RotatingFile) archiveTime(archive string) (time.Time, error) {
+	ext := filepath.Ext(r.Path)
+	prefix := strings.TrimSuffix(r.Path, ext) + "-"
+	timestamp := strings.TrimSuffix(strings.TrimSuffix(strings.TrimPrefix(archive, prefix), ".gz"), ext)
+	return time.ParseInLocation(archiveTimeFormat, timestamp, time.Local)
+}
+
+// removeOldArchives removes the oldest archives until no more than MaxBackups remain
+// and none were rotated more than MaxBackupAge ago
+func (r *RotatingFile) removeOldArchives() error {
+	if r.MaxBackups <= 0 && r.MaxBackupAge <= 0 {
+		return nil
+	}
+	archives, err := r.archives()
+	if err != nil {
+		return err
+	}
+	for len(archives) > 0 && (r.tooMany(len(archives)) || r.tooOld(archives[0])) {
+		if err := os.Remove(archives[0]); err != nil {
+			return err
+		}
+		archives = archives[1:]
+	}
+	return nil
+}
+
+func (r *RotatingFile) tooMany(archives int) bool {
+	return r.MaxBackups > 0 && archives > r.MaxBackups
+}
+
+func (r *RotatingFile) tooOld(archive string) bool {
+	if r.MaxBackupAge <= 0 {
+		return false
+	}
+	rotated, err := r.archiveTime(archive)
+	return err == nil && time.Since(rotated) > r.MaxBackupAge
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// compress replaces the file at path with a gzipped copy at path.gz
+func compress(path string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(out)
+	if _, err := io.Copy(gz, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
@@ -0,0 +1,110 @@
+package logging
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/probr/probr-sdk/config"
+)
+
+func TestRotatingFile_MaxSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "probr.log")
+	r := &RotatingFile{Path: path, MaxSize: 10, MaxBackups: 2}
+	defer r.Close()
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		if _, err := r.Write([]byte(line)); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+
+	current, _ := ioutil.ReadFile(path)
+	if string(current) != "fourth\n" {
+		t.Errorf("Expected the current file to contain only the last write, found %q", current)
+	}
+	archives, _ := r.archives()
+	if len(archives) != 2 {
+		t.Fatalf("Expected 2 archives to be retained, found %v", archives)
+	}
+	oldest, _ := ioutil.ReadFile(archives[0])
+	if string(oldest) != "second\n" {
+		t.Errorf("Expected the oldest archive to have been removed, found %q", oldest)
+	}
+}
+
+func TestRotatingFile_MaxAge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "probr.log")
+	r := &RotatingFile{Path: path, MaxAge: time.Hour, Compress: true}
+	defer r.Close()
+
+	r.Write([]byte("old\n"))
+	r.started = time.Now().Add(-2 * time.Hour)
+	r.Write([]byte("new\n"))
+
+	archives, _ := r.archives()
+	if len(archives) != 1 || !strings.HasSuffix(archives[0], ".log.gz") {
+		t.Fatalf("Expected one compressed archive, found %v", archives)
+	}
+	f, _ := os.Open(archives[0])
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("Expected a gzip archive: %v", err)
+	}
+	content, _ := ioutil.ReadAll(gz)
+	if string(content) != "old\n" {
+		t.Errorf("Unexpected archive content %q", content)
+	}
+}
+
+func TestRotatingFile_Reopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "probr.log")
+	ioutil.WriteFile(path, []byte("existing\n"), 0644)
+
+	r := &RotatingFile{Path: path, MaxSize: 12}
+	r.Write([]byte("new\n"))
+	r.Close()
+
+	archives, _ := r.archives()
+	if len(archives) != 1 {
+		t.Errorf("Expected the size of the existing file to count towards MaxSize, found archives %v", archives)
+	}
+}
+
+func TestRotatingFile_MaxBackupAge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "probr.log")
+	r := &RotatingFile{Path: path, MaxSize: 4, MaxBackupAge: time.Hour}
+	defer r.Close()
+
+	old := r.archivePath(time.Now().Add(-2 * time.Hour))
+	recent := r.archivePath(time.Now().Add(-time.Minute))
+	ioutil.WriteFile(old+".gz", []byte("old\n"), 0644)
+	ioutil.WriteFile(recent, []byte("recent\n"), 0644)
+
+	r.Write([]byte("one\n"))
+	if exists(old+".gz") || !exists(recent) {
+		t.Errorf("Expected only the archive older than MaxBackupAge to be removed when opened")
+	}
+	r.Write([]byte("two\n"))
+	if archives, _ := r.archives(); len(archives) != 2 {
+		t.Errorf("Expected recent archives to be retained, found %v", archives)
+	}
+}
+
+func TestOutput_Reconfigure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "probr.log")
+	Output(config.LogFileOpts{Path: path, MaxSize: 1, MaxBackups: 1})
+	Output(config.LogFileOpts{Path: path, MaxSize: 2, MaxBackupAge: time.Hour, Compress: true})
+
+	logFilesMux.Lock()
+	r := logFiles[path]
+	logFilesMux.Unlock()
+	if r.MaxSize != 2*1024*1024 || r.MaxBackups != 0 || r.MaxBackupAge != time.Hour || !r.Compress {
+		t.Errorf("Expected the options of the latest call to apply, found %+v", r)
+	}
+}
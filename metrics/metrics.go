@@ -0,0 +1,198 @@
+// Package metrics converts probe results into OpenMetrics text, for use with the node-exporter
+// textfile collector or for scraping directly from a local HTTP endpoint.
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/probr/probr-sdk/audit"
+)
+
+// ContentType is the media type for OpenMetrics text exposition
+const ContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
+// Options control the metric names and which tags are reported as controls
+type Options struct {
+	Namespace        string // Prefix for all metric names; defaults to "probr"
+	ControlTagPrefix string // Only scenario tags with this prefix are reported as controls; defaults to all tags
+}
+
+// metric is a single metric family with its samples
+type metric struct {
+	name    string
+	help    string
+	samples []sample
+}
+
+type sample struct {
+	labels [][2]string
+	value  float64
+}
+
+// Text renders the summary's completed probes as OpenMetrics text. It may be called while probes are running.
+func Text(summary *audit.SummaryState, opts Options) []byte {
+	if opts.Namespace == "" {
+		opts.Namespace = "probr"
+	}
+	var b bytes.Buffer
+	for _, m := range collect(summary.Snapshot(), opts) {
+		m.write(&b, opts.Namespace)
+	}
+	b.WriteString("# EOF\n")
+	return b.Bytes()
+}
+
+// WriteTextfile writes the summary as OpenMetrics text to path, which should end in '.prom' for node-exporter.
+// The file is written to a temporary location and renamed so that partial files are never collected.
+func WriteTextfile(summary *audit.SummaryState, path string, opts Options) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(Text(summary, opts)); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Handler serves the summary as OpenMetrics text. The summary is rendered on every request,
+// so results appear as soon as each probe completes.
+func Handler(summary *audit.SummaryState, opts Options) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		w.Write(Text(summary, opts))
+	})
+}
+
+// Serve starts a local HTTP server exposing the summary at /metrics. An error is returned if addr cannot be listened on.
+// The returned server should be closed by the caller.
+func Serve(addr string, summary *audit.SummaryState, opts Options) (*http.Server, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler(summary, opts))
+	server := &http.Server{Addr: listener.Addr().String(), Handler: mux}
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			log.Printf("[ERROR] Metrics server at %s stopped: %v", server.Addr, err)
+		}
+	}()
+	return server, nil
+}
+
+func collect(summary *audit.SummaryState, opts Options) []*metric {
+	result := &metric{name: "probe_result", help: "Result of each probe, set to 1 for the current result"}
+	risk := &metric{name: "probe_risk_score", help: "Sum of the severity weights of failed scenarios in each probe"}
+	duration := &metric{name: "probe_duration_seconds", help: "Time taken to execute each probe"}
+	scenarios := &metric{name: "scenarios", help: "Number of scenarios in each probe by result"}
+	controls := &metric{name: "control_pass_ratio", help: "Ratio of passed to attempted scenarios for each control tag"}
+	runRisk := &metric{name: "risk_score", help: "Sum of the risk scores of all probes"}
+	runProbes := &metric{name: "probes", help: "Number of probes by result"}
+
+	controlPassed := make(map[string]int)
+	controlAttempted := make(map[string]int)
+	for _, name := range probeNames(summary) {
+		p := summary.Probes[name]
+		probeLabels := [][2]string{{"probe", name}}
+		if pack, ok := p.Meta["group"].(string); ok {
+			probeLabels = append(probeLabels, [2]string{"pack", pack})
+		}
+
+		result.add(1, with(probeLabels, "result", p.Result)...)
+		risk.add(float64(p.RiskScore), probeLabels...)
+		duration.add(p.Duration().Seconds(), probeLabels...)
+		scenarios.add(float64(p.ScenariosSucceeded), with(probeLabels, "result", "Passed")...)
+		scenarios.add(float64(p.ScenariosFailed), with(probeLabels, "result", "Failed")...)
+		scenarios.add(float64(p.GivenNotMet), with(probeLabels, "result", "Given Not Met")...)
+
+		for _, s := range p.Scenarios {
+			for _, tag := range s.Tags {
+				if !strings.HasPrefix(tag, opts.ControlTagPrefix) || s.Result == "Given Not Met" {
+					continue
+				}
+				controlAttempted[tag]++
+				if s.Result == "Passed" {
+					controlPassed[tag]++
+				}
+			}
+		}
+	}
+
+	var tags []string
+	for tag := range controlAttempted {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	for _, tag := range tags {
+		controls.add(float64(controlPassed[tag])/float64(controlAttempted[tag]), [2]string{"control", tag})
+	}
+
+	runRisk.add(float64(summary.RiskScore))
+	runProbes.add(float64(summary.ProbesPassed), [2]string{"result", "Passed"})
+	runProbes.add(float64(summary.ProbesFailed), [2]string{"result", "Failed"})
+	runProbes.add(float64(summary.ProbesSkipped), [2]string{"result", "Skipped"})
+
+	return []*metric{result, risk, duration, scenarios, controls, runRisk, runProbes,
+		{name: "last_run_timestamp_seconds", help: "Time at which these results were rendered", samples: []sample{{value: float64(time.Now().Unix())}}},
+	}
+}
+
+func probeNames(summary *audit.SummaryState) (names []string) {
+	for name := range summary.Probes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return
+}
+
+// with copies labels before adding another, so that samples never share a backing array
+func with(labels [][2]string, name, value string) [][2]string {
+	return append(append([][2]string{}, labels...), [2]string{name, value})
+}
+
+func (m *metric) add(value float64, labels ...[2]string) {
+	m.samples = append(m.samples, sample{labels: labels, value: value})
+}
+
+func (m *metric) write(b *bytes.Buffer, namespace string) {
+	name := namespace + "_" + m.name
+	fmt.Fprintf(b, "# TYPE %s gauge\n", name)
+	fmt.Fprintf(b, "# HELP %s %s\n", name, m.help)
+	for _, s := range m.samples {
+		b.WriteString(name)
+		if len(s.labels) > 0 {
+			var pairs []string
+			for _, l := range s.labels {
+				pairs = append(pairs, fmt.Sprintf(`%s="%s"`, l[0], escape(l[1])))
+			}
+			fmt.Fprintf(b, "{%s}", strings.Join(pairs, ","))
+		}
+		fmt.Fprintf(b, " %s\n", strconv.FormatFloat(s.value, 'f', -1, 64))
+	}
+}
+
+// escape label values according to the exposition format
+func escape(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
@@ -0,0 +1,109 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cucumber/messages-go/v10"
+	"github.com/probr/probr-sdk/audit"
+)
+
+func testSummary() *audit.SummaryState {
+	s := audit.NewSummaryState("test")
+	s.AddSink(&audit.StdoutSink{Writer: ioutil.Discard})
+	s.LogProbeMeta("probe_one", "group", "pack")
+	p := s.GetProbeLog("probe_one")
+	tags := []*messages.Pickle_PickleTag{{Name: "@control-AC-1"}, {Name: "@other"}}
+	p.InitializeAuditor("passing", tags).AuditScenarioStep("step", "", nil, nil)
+	failing := p.InitializeAuditor("failing", tags)
+	failing.AuditScenarioGiven("given", "", nil, nil)
+	failing.AuditScenarioThen("then", "", nil, errors.New("failed"))
+	s.ProbeComplete("probe_one")
+	return &s
+}
+
+func TestText(t *testing.T) {
+	text := string(Text(testSummary(), Options{ControlTagPrefix: "@control"}))
+
+	expected := []string{
+		"# TYPE probr_probe_result gauge",
+		`probr_probe_result{probe="probe_one",pack="pack",result="Failed"} 1`,
+		`probr_scenarios{probe="probe_one",pack="pack",result="Passed"} 1`,
+		`probr_scenarios{probe="probe_one",pack="pack",result="Failed"} 1`,
+		`probr_control_pass_ratio{control="@control-AC-1"} 0.5`,
+		`probr_probes{result="Failed"} 1`,
+	}
+	for _, line := range expected {
+		if !strings.Contains(text, line+"\n") {
+			t.Errorf("Expected line '%s' in output:\n%s", line, text)
+		}
+	}
+	if strings.Contains(text, "@other") {
+		t.Errorf("Tags without the control prefix should not be reported")
+	}
+	if !strings.HasSuffix(text, "# EOF\n") {
+		t.Errorf("Expected output to end with '# EOF'")
+	}
+}
+
+func TestWriteTextfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "probr.prom")
+	if err := WriteTextfile(testSummary(), path, Options{Namespace: "custom"}); err != nil {
+		t.Fatalf("WriteTextfile() error = %v", err)
+	}
+	data, _ := ioutil.ReadFile(path)
+	if !strings.Contains(string(data), "custom_probe_result") {
+		t.Errorf("Expected custom namespace in output:\n%s", data)
+	}
+	files, _ := ioutil.ReadDir(filepath.Dir(path))
+	if len(files) != 1 {
+		t.Errorf("Expected temporary file to be removed, found %d files", len(files))
+	}
+}
+
+func TestHandler(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Handler(testSummary(), Options{}).ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if rec.Header().Get("Content-Type") != ContentType {
+		t.Errorf("Content-Type = %s, Expected: %s", rec.Header().Get("Content-Type"), ContentType)
+	}
+	if !strings.Contains(rec.Body.String(), "probr_risk_score") {
+		t.Errorf("Expected metrics in response body:\n%s", rec.Body.String())
+	}
+}
+
+func TestText_Running(t *testing.T) {
+	s := testSummary()
+	s.GetProbeLog("probe_two").InitializeAuditor("running", nil)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			s.LogProbeMeta(fmt.Sprintf("probe_%d", i), "group", "pack")
+		}
+	}()
+	for i := 0; i < 10; i++ {
+		if text := string(Text(s, Options{})); strings.Contains(text, "probe_two") {
+			t.Errorf("Probes that have not completed should not be reported:\n%s", text)
+		}
+	}
+	<-done
+}
+
+func TestServe(t *testing.T) {
+	server, err := Serve("127.0.0.1:0", testSummary(), Options{})
+	if err != nil {
+		t.Fatalf("Serve() error = %v", err)
+	}
+	defer server.Close()
+
+	if _, err := Serve(server.Addr, testSummary(), Options{}); err == nil {
+		t.Errorf("Expected an error when the address is already in use")
+	}
+}
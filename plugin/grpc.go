@@ -0,0 +1,434 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	hcplugin "github.com/hashicorp/go-plugin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/probr/probr-sdk/logging"
+	"github.com/probr/probr-sdk/plugin/proto"
+)
+
+// ServicePackGRPCPlugin is the implementation of plugin.GRPCPlugin, serving the API defined in
+// plugin/proto/servicepack.proto. It is used when the host and pack negotiate GRPCProtocolVersion.
+type ServicePackGRPCPlugin struct {
+	hcplugin.NetRPCUnsupportedPlugin
+
+	// Impl Injection; one of these should be set when serving
+	Impl   ServicePack
+	ImplV2 ServicePackV2
+}
+
+// GRPCServer implements gRPC server
+func (p *ServicePackGRPCPlugin) GRPCServer(broker *hcplugin.GRPCBroker, s *grpc.Server) error {
+	proto.RegisterServicePackServer(s, &ServicePackGRPCServer{Impl: p.Impl, ImplV2: p.ImplV2})
+	return nil
+}
+
+// GRPCClient implements gRPC client
+func (p *ServicePackGRPCPlugin) GRPCClient(ctx context.Context, broker *hcplugin.GRPCBroker, c *grpc.ClientConn) (interface{}, error) {
+	return &ServicePackGRPC{client: proto.NewServicePackClient(c)}, nil
+}
+
+// ServicePackGRPC is an implementation of ServicePackClient that talks over gRPC.
+// Like ServicePackRPC, it negotiates the API version with the pack via Info.
+type ServicePackGRPC struct {
+	client proto.ServicePackClient
+
+	once sync.Once
+	info PackInfo
+	err  error
+}
+
+// Info returns the pack's description, or only its API version if the pack only implements ServicePack
+func (g *ServicePackGRPC) Info() (PackInfo, error) {
+	g.once.Do(func() {
+		resp, err := g.client.Info(context.Background(), &proto.InfoRequest{HostApiVersion: APIVersion})
+		if err != nil {
+			g.err = err
+			return
+		}
+		g.info, g.err = negotiatedInfo(PackInfo{
+			Name:         resp.Name,
+			Version:      resp.Version,
+			Tags:         resp.Tags,
+			APIVersion:   int(resp.ApiVersion),
+			Capabilities: capabilitiesFromProto(resp.Capabilities),
+		})
+	})
+	return g.info, g.err
+}
+
+// ListProbes returns the probes that match a tag expression
+func (g *ServicePackGRPC) ListProbes(tags string) ([]ProbeInfo, error) {
+	if err := g.require(CapabilityListProbes, "ListProbes"); err != nil {
+		return nil, err
+	}
+	resp, err := g.client.ListProbes(context.Background(), &proto.ListProbesRequest{Tags: tags})
+	if err != nil {
+		return nil, err
+	}
+	var probes []ProbeInfo
+	for _, p := range resp.Probes {
+		probes = append(probes, ProbeInfo{Name: p.Name, Tags: p.Tags})
+	}
+	return probes, nil
+}
+
+// Configure provides a vars file to the pack
+func (g *ServicePackGRPC) Configure(config []byte) error {
+	if err := g.require(CapabilityConfigure, "Configure"); err != nil {
+		return err
+	}
+	_, err := g.client.Configure(context.Background(), &proto.ConfigureRequest{Config: config})
+	return err
+}
+
+// RunProbes runs every probe, returning an error if the run failed, as for hosts written against ServicePack
+func (g *ServicePackGRPC) RunProbes() error {
+	return runError(g.Run(RunRequest{}))
+}
+
+// Run runs the requested probes. Packs that only implement ServicePack always run every probe,
+// so a request that selects probes returns an UnsupportedError.
+func (g *ServicePackGRPC) Run(request RunRequest) (*RunSummary, error) {
+	info, err := g.Info()
+	if err != nil {
+		return nil, err
+	}
+	if (request.Tags != "" || len(request.Probes) > 0) && !info.Supports(CapabilitySelectProbes) {
+		return nil, &UnsupportedError{Method: "RunProbes with a RunRequest", APIVersion: info.APIVersion}
+	}
+	resp, err := g.client.RunProbes(context.Background(), &proto.RunRequest{Tags: request.Tags, Probes: request.Probes})
+	if err != nil {
+		return &RunSummary{ExitCode: 1, Status: err.Error()}, err
+	}
+	return runSummaryFromProto(resp), nil
+}
+
+// RunProbesWithEvents runs the requested probes, streaming their progress and logs to handle.
+// Packs built against SDKs that do not stream events are run via Run.
+func (g *ServicePackGRPC) RunProbesWithEvents(request RunRequest, logLevel string, handle func(logging.Event)) (*RunSummary, error) {
+	info, err := g.Info()
+	if err != nil {
+		return nil, err
+	}
+	if (request.Tags != "" || len(request.Probes) > 0) && !info.Supports(CapabilitySelectProbes) {
+		return nil, &UnsupportedError{Method: "RunProbes with a RunRequest", APIVersion: info.APIVersion}
+	}
+	stream, err := g.client.RunProbesStream(context.Background(), &proto.RunStreamRequest{
+		Request:  &proto.RunRequest{Tags: request.Tags, Probes: request.Probes},
+		LogLevel: logLevel,
+	})
+	if err != nil {
+		return &RunSummary{ExitCode: 1, Status: err.Error()}, err
+	}
+	var summary *RunSummary
+	for {
+		msg, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if status.Code(err) == codes.Unimplemented && summary == nil {
+			return g.Run(request)
+		}
+		if err != nil {
+			if summary == nil {
+				summary = &RunSummary{ExitCode: 1, Status: err.Error()}
+			}
+			return summary, err
+		}
+		if msg.Event != nil {
+			handle(eventFromProto(msg.Event))
+		}
+		if msg.Summary != nil {
+			summary = runSummaryFromProto(msg.Summary)
+		}
+	}
+	if summary == nil {
+		summary = &RunSummary{}
+	}
+	return summary, nil
+}
+
+// Cancel stops a run that is in progress
+func (g *ServicePackGRPC) Cancel() error {
+	if err := g.require(CapabilityCancel, "Cancel"); err != nil {
+		return err
+	}
+	_, err := g.client.Cancel(context.Background(), &proto.Empty{})
+	return err
+}
+
+// require returns an UnsupportedError if the pack does not advertise the capability
+func (g *ServicePackGRPC) require(capability Capability, method string) error {
+	info, err := g.Info()
+	if err != nil {
+		return err
+	}
+	if !info.Supports(capability) {
+		return &UnsupportedError{Method: method, APIVersion: info.APIVersion}
+	}
+	return nil
+}
+
+// ServicePackGRPCServer is the gRPC server that ServicePackGRPC talks to
+type ServicePackGRPCServer struct {
+	// This is the real implementation; one of these should be set
+	Impl   ServicePack
+	ImplV2 ServicePackV2
+}
+
+// Info is a wrapper for interface implementation. The host provides its own API version.
+func (s *ServicePackGRPCServer) Info(ctx context.Context, req *proto.InfoRequest) (*proto.PackInfo, error) {
+	info, err := servedInfo(s.ImplV2, int(req.HostApiVersion), CapabilityEvents)
+	if err != nil {
+		return nil, err
+	}
+	return &proto.PackInfo{
+		Name:         info.Name,
+		Version:      info.Version,
+		Tags:         info.Tags,
+		ApiVersion:   int32(info.APIVersion),
+		Capabilities: capabilitiesToProto(info.Capabilities),
+	}, nil
+}
+
+// ListProbes is a wrapper for interface implementation
+func (s *ServicePackGRPCServer) ListProbes(ctx context.Context, req *proto.ListProbesRequest) (*proto.ListProbesResponse, error) {
+	if s.ImplV2 == nil {
+		return nil, &UnsupportedError{Method: "ListProbes", APIVersion: 1}
+	}
+	probes, err := s.ImplV2.ListProbes(req.Tags)
+	if err != nil {
+		return nil, err
+	}
+	resp := &proto.ListProbesResponse{}
+	for _, p := range probes {
+		resp.Probes = append(resp.Probes, &proto.ProbeInfo{Name: p.Name, Tags: p.Tags})
+	}
+	return resp, nil
+}
+
+// Configure is a wrapper for interface implementation
+func (s *ServicePackGRPCServer) Configure(ctx context.Context, req *proto.ConfigureRequest) (*proto.Empty, error) {
+	if s.ImplV2 == nil {
+		return nil, &UnsupportedError{Method: "Configure", APIVersion: 1}
+	}
+	return &proto.Empty{}, s.ImplV2.Configure(req.Config)
+}
+
+// RunProbes is a wrapper for interface implementation. Packs that only implement ServicePack run every probe.
+func (s *ServicePackGRPCServer) RunProbes(ctx context.Context, req *proto.RunRequest) (*proto.RunSummary, error) {
+	if s.ImplV2 == nil {
+		if err := s.Impl.RunProbes(); err != nil {
+			return nil, err
+		}
+		return &proto.RunSummary{Status: "Complete"}, nil
+	}
+	summary, err := s.ImplV2.RunProbes(RunRequest{Tags: req.Tags, Probes: req.Probes})
+	if err != nil {
+		return nil, err
+	}
+	return runSummaryToProto(summary), nil
+}
+
+// RunProbesStream is a wrapper for the interface implementation of RunProbes, which sends the events published
+// and lines logged during the run, followed by the summary. Events are queued and sent by a separate goroutine,
+// so that a slow host never blocks logging or the probes; if the queue is full, events are dropped and counted.
+func (s *ServicePackGRPCServer) RunProbesStream(req *proto.RunStreamRequest, stream proto.ServicePack_RunProbesStreamServer) error {
+	queue := newEventQueue(stream, streamQueueSize)
+	unsubscribe := logging.Subscribe(queue.add, req.LogLevel)
+
+	request := req.Request
+	if request == nil {
+		request = &proto.RunRequest{}
+	}
+	summary, err := s.RunProbes(stream.Context(), request)
+	unsubscribe()
+	sendErr := queue.close()
+	if sendErr == nil && summary != nil {
+		sendErr = stream.Send(&proto.RunEvent{Summary: summary})
+	}
+	if err != nil {
+		return err
+	}
+	return sendErr
+}
+
+// streamQueueSize is the number of events that may wait to be sent to the host before further events are dropped
+const streamQueueSize = 1024
+
+// eventQueue sends events to the host from its own goroutine
+type eventQueue struct {
+	stream  proto.ServicePack_RunProbesStreamServer
+	events  chan *proto.Event
+	done    chan struct{}
+	err     error
+	mux     sync.RWMutex
+	closed  bool
+	dropped int64
+}
+
+func newEventQueue(stream proto.ServicePack_RunProbesStreamServer, size int) *eventQueue {
+	q := &eventQueue{stream: stream, events: make(chan *proto.Event, size), done: make(chan struct{})}
+	go q.drain()
+	return q
+}
+
+// add queues the event without blocking, dropping it if the queue is full. It must not log, see logging.Subscribe.
+func (q *eventQueue) add(e logging.Event) {
+	q.mux.RLock()
+	defer q.mux.RUnlock()
+	if q.closed {
+		return
+	}
+	select {
+	case q.events <- eventToProto(e):
+	default:
+		atomic.AddInt64(&q.dropped, 1)
+	}
+}
+
+func (q *eventQueue) drain() {
+	defer close(q.done)
+	for e := range q.events {
+		if q.err == nil {
+			q.err = q.stream.Send(&proto.RunEvent{Event: e})
+		}
+	}
+}
+
+// close sends the queued events, followed by a warning if any were dropped, and returns the first error from sending
+func (q *eventQueue) close() error {
+	q.mux.Lock()
+	q.closed = true
+	close(q.events)
+	q.mux.Unlock()
+	<-q.done
+
+	if dropped := atomic.LoadInt64(&q.dropped); dropped > 0 && q.err == nil {
+		q.err = q.stream.Send(&proto.RunEvent{Event: eventToProto(logging.Event{
+			Type:    logging.LogLine,
+			Time:    time.Now(),
+			Level:   "WARN",
+			Message: fmt.Sprintf("%d events were dropped as they could not be sent to the host quickly enough", dropped),
+		})})
+	}
+	return q.err
+}
+
+// Cancel is a wrapper for interface implementation
+func (s *ServicePackGRPCServer) Cancel(ctx context.Context, req *proto.Empty) (*proto.Empty, error) {
+	if s.ImplV2 == nil {
+		return nil, &UnsupportedError{Method: "Cancel", APIVersion: 1}
+	}
+	return &proto.Empty{}, s.ImplV2.Cancel()
+}
+
+func runSummaryToProto(s *RunSummary) *proto.RunSummary {
+	if s == nil {
+		return &proto.RunSummary{}
+	}
+	resp := &proto.RunSummary{
+		ExitCode:      int32(s.ExitCode),
+		Status:        s.Status,
+		ProbesPassed:  int32(s.ProbesPassed),
+		ProbesFailed:  int32(s.ProbesFailed),
+		ProbesSkipped: int32(s.ProbesSkipped),
+		RiskScore:     int32(s.RiskScore),
+	}
+	for _, p := range s.Probes {
+		resp.Probes = append(resp.Probes, &proto.ProbeResult{
+			Name:               p.Name,
+			Result:             p.Result,
+			ScenariosAttempted: int32(p.ScenariosAttempted),
+			ScenariosSucceeded: int32(p.ScenariosSucceeded),
+			ScenariosFailed:    int32(p.ScenariosFailed),
+			RiskScore:          int32(p.RiskScore),
+			AuditPath:          p.AuditPath,
+			LogPath:            p.LogPath,
+		})
+	}
+	return resp
+}
+
+func runSummaryFromProto(s *proto.RunSummary) *RunSummary {
+	summary := &RunSummary{
+		ExitCode:      int(s.ExitCode),
+		Status:        s.Status,
+		ProbesPassed:  int(s.ProbesPassed),
+		ProbesFailed:  int(s.ProbesFailed),
+		ProbesSkipped: int(s.ProbesSkipped),
+		RiskScore:     int(s.RiskScore),
+	}
+	for _, p := range s.Probes {
+		summary.Probes = append(summary.Probes, ProbeResult{
+			Name:               p.Name,
+			Result:             p.Result,
+			ScenariosAttempted: int(p.ScenariosAttempted),
+			ScenariosSucceeded: int(p.ScenariosSucceeded),
+			ScenariosFailed:    int(p.ScenariosFailed),
+			RiskScore:          int(p.RiskScore),
+			AuditPath:          p.AuditPath,
+			LogPath:            p.LogPath,
+		})
+	}
+	return summary
+}
+
+func eventToProto(e logging.Event) *proto.Event {
+	return &proto.Event{
+		Type:         string(e.Type),
+		TimeUnixNano: e.Time.UnixNano(),
+		RunId:        e.RunID,
+		Pack:         e.Pack,
+		Probe:        e.Probe,
+		Scenario:     e.Scenario,
+		Step:         e.Step,
+		Result:       e.Result,
+		Error:        e.Error,
+		Level:        e.Level,
+		Message:      e.Message,
+	}
+}
+
+func eventFromProto(e *proto.Event) logging.Event {
+	return logging.Event{
+		Type: logging.EventType(e.Type),
+		Time: time.Unix(0, e.TimeUnixNano),
+		Fields: logging.Fields{
+			RunID:    e.RunId,
+			Pack:     e.Pack,
+			Probe:    e.Probe,
+			Scenario: e.Scenario,
+			Step:     e.Step,
+		},
+		Result:  e.Result,
+		Error:   e.Error,
+		Level:   e.Level,
+		Message: e.Message,
+	}
+}
+
+func capabilitiesToProto(capabilities []Capability) (names []string) {
+	for _, c := range capabilities {
+		names = append(names, string(c))
+	}
+	return
+}
+
+func capabilitiesFromProto(names []string) (capabilities []Capability) {
+	for _, name := range names {
+		capabilities = append(capabilities, Capability(name))
+	}
+	return
+}
@@ -0,0 +1,184 @@
+package plugin
+
+import (
+	"errors"
+	"io/ioutil"
+	"log"
+	"strings"
+	"testing"
+
+	hcplugin "github.com/hashicorp/go-plugin"
+	"github.com/probr/probr-sdk/logging"
+	"github.com/probr/probr-sdk/plugin/proto"
+)
+
+// dispenseGRPC starts the plugin in-process over gRPC and returns the client
+func dispenseGRPC(t *testing.T, p hcplugin.Plugin) *ServicePackGRPC {
+	client, server := hcplugin.TestPluginGRPCConn(t, map[string]hcplugin.Plugin{ServicePackPluginName: p})
+	t.Cleanup(func() {
+		client.Close()
+		server.Stop()
+	})
+	raw, err := client.Dispense(ServicePackPluginName)
+	if err != nil {
+		t.Fatalf("Dispense() error = %v", err)
+	}
+	return raw.(*ServicePackGRPC)
+}
+
+func TestServicePackGRPC_V2(t *testing.T) {
+	pack := &testPackV2{}
+	sp := dispenseGRPC(t, &ServicePackGRPCPlugin{ImplV2: pack})
+
+	info, err := sp.Info()
+	if err != nil || info.Name != "test" || info.Tags[0] != "@test" || info.APIVersion != APIVersion {
+		t.Errorf("Info() = %+v, %v", info, err)
+	}
+	probes, err := sp.ListProbes("@test")
+	if err != nil || len(probes) != 1 || probes[0].Name != "probe" {
+		t.Errorf("ListProbes() = %+v, %v", probes, err)
+	}
+	if _, err := sp.ListProbes("invalid"); err == nil || !strings.Contains(err.Error(), "invalid tags") {
+		t.Errorf("Expected the pack's error to be returned, got %v", err)
+	}
+	if err := sp.Configure([]byte("LogLevel: INFO")); err != nil || string(pack.config) != "LogLevel: INFO" {
+		t.Errorf("Configure() error = %v, config = %s", err, pack.config)
+	}
+	summary, err := sp.Run(RunRequest{Tags: "@test", Probes: []string{"probe"}})
+	if err != nil || summary.ExitCode != 1 || summary.ProbesFailed != 1 || pack.request.Probes[0] != "probe" {
+		t.Errorf("RunProbes() = %+v, %v", summary, err)
+	}
+	if err := sp.Cancel(); err != nil || !pack.cancelled {
+		t.Errorf("Cancel() error = %v", err)
+	}
+}
+
+func TestServicePackGRPC_V1(t *testing.T) {
+	pack := &testPackV1{}
+	sp := dispenseGRPC(t, &ServicePackGRPCPlugin{Impl: pack})
+
+	if info, err := sp.Info(); err != nil || info.APIVersion != 1 {
+		t.Errorf("Info() = %+v, %v", info, err)
+	}
+	var unsupported *UnsupportedError
+	if _, err := sp.ListProbes(""); !errors.As(err, &unsupported) {
+		t.Errorf("Expected an UnsupportedError, got %v", err)
+	}
+	if _, err := sp.Run(RunRequest{Tags: "@test"}); err == nil {
+		t.Errorf("Expected an error when selecting probes from a version 1 pack")
+	}
+	if summary, err := sp.Run(RunRequest{}); err != nil || summary.ExitCode != 0 || pack.runs != 1 {
+		t.Errorf("RunProbes() = %+v, %v", summary, err)
+	}
+}
+
+func TestRunSummaryProto(t *testing.T) {
+	summary := &RunSummary{
+		ExitCode:     1,
+		Status:       "Complete - 1/2 Succeeded",
+		ProbesPassed: 1,
+		ProbesFailed: 1,
+		RiskScore:    2,
+		Probes: []ProbeResult{
+			{Name: "a", Result: "Success", ScenariosAttempted: 1, ScenariosSucceeded: 1, AuditPath: "audit/a.json", LogPath: "audit/a.log"},
+			{Name: "b", Result: "Failed", ScenariosAttempted: 1, ScenariosFailed: 1, RiskScore: 2},
+		},
+	}
+	got := runSummaryFromProto(runSummaryToProto(summary))
+	if got.Status != summary.Status || got.RiskScore != 2 || len(got.Probes) != 2 || got.Probes[0] != summary.Probes[0] || got.Probes[1] != summary.Probes[1] {
+		t.Errorf("Round trip = %+v, want %+v", got, summary)
+	}
+}
+
+func TestPluginSets(t *testing.T) {
+	sets := PluginSets(&testPackV1{}, nil)
+	if _, ok := sets[NetRPCProtocolVersion][ServicePackPluginName].(*ServicePackPlugin); !ok {
+		t.Errorf("Expected net/rpc to be served as protocol version %d", NetRPCProtocolVersion)
+	}
+	if _, ok := sets[GRPCProtocolVersion][ServicePackPluginName].(hcplugin.GRPCPlugin); !ok {
+		t.Errorf("Expected gRPC to be served as protocol version %d", GRPCProtocolVersion)
+	}
+}
+
+// eventsPack publishes an event and logs a line during its run
+type eventsPack struct {
+	testPackV2
+}
+
+func (p *eventsPack) RunProbes(request RunRequest) (*RunSummary, error) {
+	logging.Publish(logging.Event{Type: logging.ProbeFinished, Fields: logging.Fields{Probe: "probe"}, Result: logging.ResultPassed})
+	log.Printf("[WARN] message from the pack")
+	return &RunSummary{Status: "Complete - 1/1 Succeeded", ProbesPassed: 1}, nil
+}
+
+func TestServicePackGRPC_RunProbesWithEvents(t *testing.T) {
+	defer logging.UseLogger("default")
+	logging.UseLogger("grpc-events-test", "DEBUG", ioutil.Discard, false)
+	sp := dispenseGRPC(t, &ServicePackGRPCPlugin{ImplV2: &eventsPack{}})
+
+	var events []logging.Event
+	summary, err := sp.RunProbesWithEvents(RunRequest{}, "INFO", func(e logging.Event) { events = append(events, e) })
+	if err != nil || summary.ProbesPassed != 1 {
+		t.Errorf("RunProbesWithEvents() = %+v, %v", summary, err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %+v", events)
+	}
+	if e := events[0]; e.Type != logging.ProbeFinished || e.Probe != "probe" || e.Result != logging.ResultPassed || e.Time.IsZero() {
+		t.Errorf("Unexpected progress event: %+v", e)
+	}
+	if e := events[1]; e.Type != logging.LogLine || e.Level != "WARN" || e.Message != "message from the pack" {
+		t.Errorf("Unexpected log event: %+v", e)
+	}
+}
+
+// blockedStream records the events sent to it, blocking each send until it is released
+type blockedStream struct {
+	proto.ServicePack_RunProbesStreamServer
+	sending chan struct{}
+	release chan struct{}
+	sent    []*proto.RunEvent
+}
+
+func (b *blockedStream) Send(e *proto.RunEvent) error {
+	b.sending <- struct{}{}
+	<-b.release
+	b.sent = append(b.sent, e)
+	return nil
+}
+
+func TestEventQueue_Overflow(t *testing.T) {
+	stream := &blockedStream{sending: make(chan struct{}, 10), release: make(chan struct{})}
+	q := newEventQueue(stream, 1)
+
+	q.add(logging.Event{Message: "sending"})
+	<-stream.sending // The first event is being sent, and the host is slow to receive it
+	for _, message := range []string{"queued", "dropped", "dropped"} {
+		q.add(logging.Event{Message: message})
+	}
+	close(stream.release)
+	if err := q.close(); err != nil {
+		t.Fatalf("close() error = %v", err)
+	}
+	q.add(logging.Event{Message: "after close"}) // Events published after the run are ignored
+
+	var messages []string
+	for _, e := range stream.sent {
+		messages = append(messages, e.Event.Message)
+	}
+	if len(messages) != 3 || messages[0] != "sending" || messages[1] != "queued" || !strings.HasPrefix(messages[2], "2 events were dropped") {
+		t.Errorf("Unexpected events sent: %v", messages)
+	}
+}
+
+func TestServicePackRPC_RunProbesWithEvents(t *testing.T) {
+	pack := &testPackV2{}
+	sp := dispense(t, &ServicePackPlugin{ImplV2: pack})
+
+	summary, err := sp.RunProbesWithEvents(RunRequest{Tags: "@test"}, "INFO", func(logging.Event) {
+		t.Errorf("Events are not expected over net/rpc")
+	})
+	if err != nil || summary.ProbesFailed != 1 || pack.request.Tags != "@test" {
+		t.Errorf("RunProbesWithEvents() = %+v, %v", summary, err)
+	}
+}
@@ -0,0 +1,37 @@
+package plugin
+
+import (
+	"context"
+	"net"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/probr/probr-sdk/plugin/proto"
+)
+
+// inProcessBufferSize is the size of the buffer used by the in-memory connection to each in-process pack
+const inProcessBufferSize = 1024 * 1024
+
+// serveInProcess serves the pack over gRPC via an in-memory connection, so that hosts can be tested against
+// the same client and server as packs run from a binary, without building one
+func serveInProcess(pack ServicePackV2) (ServicePackClient, func(), error) {
+	listener := bufconn.Listen(inProcessBufferSize)
+	server := grpc.NewServer()
+	proto.RegisterServicePackServer(server, &ServicePackGRPCServer{ImplV2: pack})
+	go server.Serve(listener)
+
+	conn, err := grpc.Dial("bufconn",
+		grpc.WithInsecure(),
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return listener.Dial() }),
+	)
+	if err != nil {
+		server.Stop()
+		return nil, nil, err
+	}
+	kill := func() {
+		conn.Close()
+		server.Stop()
+	}
+	return &ServicePackGRPC{client: proto.NewServicePackClient(conn)}, kill, nil
+}
@@ -0,0 +1,245 @@
+package plugin
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	hclog "github.com/hashicorp/go-hclog"
+	hcplugin "github.com/hashicorp/go-plugin"
+	"github.com/probr/probr-sdk/logging"
+)
+
+// Pack is a service pack that may be started by a Manager
+type Pack struct {
+	Name     string
+	Path     string // Path to the pack's binary; empty for packs served in-process
+	Checksum string // Hex encoded SHA-256 checksum of the binary, verified before it is started if set
+}
+
+// Result is the outcome of running a single pack via Manager.RunAll
+type Result struct {
+	Pack    Pack
+	Summary *RunSummary
+	Err     error
+}
+
+// Manager discovers, starts and runs service packs on behalf of a host such as probr core.
+// Close should be called once the host is finished with the manager, to stop any packs that are still running.
+type Manager struct {
+	Dir              string                             // Directory searched for pack binaries by Discover
+	Checksums        map[string]string                  // Hex encoded SHA-256 checksums by binary file name, see LoadChecksums
+	RequireChecksums bool                               // Refuse to start packs that do not have a checksum
+	MaxConcurrent    int                                // Maximum number of packs run at once by RunAll; zero for no limit
+	LogLevel         string                             // Level at or above which pack logs are passed to Events
+	Events           func(pack string, e logging.Event) // Receives the progress of each pack during RunAll. May be called concurrently.
+	Logger           hclog.Logger                       // Used by go-plugin for each client
+
+	// InProcess packs are served within the host rather than from a binary, for testing hosts.
+	// As they share the host's logging package, events published by one in-process pack are seen by all of them.
+	InProcess map[string]ServicePackV2
+
+	mux       sync.Mutex
+	instances map[*Instance]struct{}
+}
+
+// Instance is a running service pack. Kill must be called once it is no longer required.
+type Instance struct {
+	Pack
+	ServicePackClient
+
+	once    sync.Once
+	kill    func()
+	manager *Manager
+}
+
+// Kill stops the pack
+func (i *Instance) Kill() {
+	i.once.Do(func() {
+		i.kill()
+		i.manager.mux.Lock()
+		delete(i.manager.instances, i)
+		i.manager.mux.Unlock()
+	})
+}
+
+// LoadChecksums reads checksums in the format written by sha256sum, such as a SHA256SUMS file released with packs
+func LoadChecksums(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	checksums := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%s:%d: expected '<checksum>  <file name>'", path, line)
+		}
+		if _, err := hex.DecodeString(fields[0]); err != nil || len(fields[0]) != sha256.Size*2 {
+			return nil, fmt.Errorf("%s:%d: invalid SHA-256 checksum '%s'", path, line, fields[0])
+		}
+		checksums[strings.TrimPrefix(fields[1], "*")] = strings.ToLower(fields[0])
+	}
+	return checksums, scanner.Err()
+}
+
+// Discover lists the executables in Dir, and any InProcess packs, ordered by name
+func (m *Manager) Discover() (packs []Pack, err error) {
+	for name := range m.InProcess {
+		packs = append(packs, Pack{Name: name})
+	}
+	if m.Dir != "" {
+		files, err := ioutil.ReadDir(m.Dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			path := filepath.Join(m.Dir, file.Name())
+			if info, err := os.Stat(path); err != nil || !isExecutable(info) { // Stat follows symlinks
+				continue
+			}
+			packs = append(packs, Pack{
+				Name:     strings.TrimSuffix(file.Name(), ".exe"),
+				Path:     path,
+				Checksum: m.Checksums[file.Name()],
+			})
+		}
+	}
+	sort.Slice(packs, func(i, j int) bool { return packs[i].Name < packs[j].Name })
+	return
+}
+
+func isExecutable(info os.FileInfo) bool {
+	if !info.Mode().IsRegular() || strings.HasPrefix(info.Name(), ".") {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		return strings.HasSuffix(info.Name(), ".exe")
+	}
+	return info.Mode()&0111 != 0
+}
+
+// Start verifies the pack's checksum, launches it using the shared handshake, and dispenses its client.
+// A VersionError is returned if the pack and host were built against incompatible versions of the SDK.
+func (m *Manager) Start(pack Pack) (*Instance, error) {
+	var (
+		client ServicePackClient
+		kill   func()
+		err    error
+	)
+	if impl, ok := m.InProcess[pack.Name]; ok && pack.Path == "" {
+		client, kill, err = serveInProcess(impl)
+	} else {
+		client, kill, err = m.launch(pack)
+	}
+	if err == nil {
+		if _, err = client.Info(); err != nil {
+			kill()
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to start service pack '%s': %w", pack.Name, err)
+	}
+
+	instance := &Instance{Pack: pack, ServicePackClient: client, kill: kill, manager: m}
+	m.mux.Lock()
+	if m.instances == nil {
+		m.instances = make(map[*Instance]struct{})
+	}
+	m.instances[instance] = struct{}{}
+	m.mux.Unlock()
+	return instance, nil
+}
+
+func (m *Manager) launch(pack Pack) (ServicePackClient, func(), error) {
+	config := clientConfig(exec.Command(pack.Path), m.Logger)
+	if pack.Checksum != "" {
+		checksum, err := hex.DecodeString(pack.Checksum)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid checksum: %v", err)
+		}
+		config.SecureConfig = &hcplugin.SecureConfig{Checksum: checksum, Hash: sha256.New()}
+	} else if m.RequireChecksums {
+		return nil, nil, fmt.Errorf("no checksum was provided for %s", pack.Path)
+	}
+
+	hcclient := hcplugin.NewClient(config)
+	client, err := Dispense(hcclient)
+	if err != nil {
+		hcclient.Kill()
+		return nil, nil, err
+	}
+	return client, hcclient.Kill, nil
+}
+
+// RunAll starts each pack, runs the requested probes, and stops the pack again, running up to MaxConcurrent
+// packs at once. Results are returned in the same order as packs.
+func (m *Manager) RunAll(packs []Pack, request RunRequest) []Result {
+	limit := m.MaxConcurrent
+	if limit <= 0 {
+		limit = len(packs)
+	}
+	slots := make(chan struct{}, limit)
+	results := make([]Result, len(packs))
+
+	var wg sync.WaitGroup
+	for i, pack := range packs {
+		wg.Add(1)
+		go func(i int, pack Pack) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			results[i] = m.run(pack, request)
+		}(i, pack)
+	}
+	wg.Wait()
+	return results
+}
+
+func (m *Manager) run(pack Pack, request RunRequest) Result {
+	result := Result{Pack: pack}
+	instance, err := m.Start(pack)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	defer instance.Kill()
+
+	if m.Events == nil {
+		result.Summary, result.Err = instance.Run(request)
+	} else {
+		result.Summary, result.Err = instance.RunProbesWithEvents(request, m.LogLevel, func(e logging.Event) {
+			m.Events(pack.Name, e)
+		})
+	}
+	return result
+}
+
+// Close stops every pack that is still running
+func (m *Manager) Close() {
+	m.mux.Lock()
+	var running []*Instance
+	for instance := range m.instances {
+		running = append(running, instance)
+	}
+	m.mux.Unlock()
+	for _, instance := range running {
+		instance.Kill()
+	}
+}
@@ -0,0 +1,177 @@
+package plugin
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/probr/probr-sdk/logging"
+)
+
+func TestLoadChecksums(t *testing.T) {
+	checksum := strings.Repeat("ab", sha256.Size)
+	path := filepath.Join(t.TempDir(), "SHA256SUMS")
+	ioutil.WriteFile(path, []byte(fmt.Sprintf("# packs\n%s  pack-a\n%s *pack-b.exe\n", checksum, strings.ToUpper(checksum))), 0644)
+
+	checksums, err := LoadChecksums(path)
+	if err != nil || checksums["pack-a"] != checksum || checksums["pack-b.exe"] != checksum {
+		t.Errorf("LoadChecksums() = %v, %v", checksums, err)
+	}
+
+	ioutil.WriteFile(path, []byte("1234  pack-a\n"), 0644)
+	if _, err := LoadChecksums(path); err == nil || !strings.Contains(err.Error(), ":1: invalid SHA-256 checksum") {
+		t.Errorf("Expected an invalid checksum to be reported, got %v", err)
+	}
+}
+
+func TestManager_Discover(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Executables are identified by extension on windows")
+	}
+	dir := t.TempDir()
+	ioutil.WriteFile(filepath.Join(dir, "pack-a"), []byte{}, 0755)
+	ioutil.WriteFile(filepath.Join(dir, ".hidden"), []byte{}, 0755)
+	ioutil.WriteFile(filepath.Join(dir, "README.md"), []byte{}, 0644)
+	os.Mkdir(filepath.Join(dir, "subdir"), 0755)
+
+	m := &Manager{
+		Dir:       dir,
+		Checksums: map[string]string{"pack-a": "abcd"},
+		InProcess: map[string]ServicePackV2{"pack-b": &testPackV2{}},
+	}
+	packs, err := m.Discover()
+	expected := []Pack{{Name: "pack-a", Path: filepath.Join(dir, "pack-a"), Checksum: "abcd"}, {Name: "pack-b"}}
+	if err != nil || len(packs) != 2 || packs[0] != expected[0] || packs[1] != expected[1] {
+		t.Errorf("Discover() = %+v, %v, Expected: %+v", packs, err, expected)
+	}
+}
+
+// concurrencyPack records the number of packs that are running at once
+type concurrencyPack struct {
+	testPackV2
+	mux     *sync.Mutex
+	running *int
+	max     *int
+}
+
+func (p *concurrencyPack) RunProbes(request RunRequest) (*RunSummary, error) {
+	p.mux.Lock()
+	*p.running++
+	if *p.running > *p.max {
+		*p.max = *p.running
+	}
+	p.mux.Unlock()
+	time.Sleep(50 * time.Millisecond)
+	p.mux.Lock()
+	*p.running--
+	p.mux.Unlock()
+	return &RunSummary{Status: "Complete - 1/1 Succeeded", ProbesPassed: 1}, nil
+}
+
+func TestManager_RunAll(t *testing.T) {
+	var mux sync.Mutex
+	var running, max int
+	m := &Manager{MaxConcurrent: 2, InProcess: make(map[string]ServicePackV2)}
+	defer m.Close()
+	for i := 0; i < 4; i++ {
+		m.InProcess[fmt.Sprintf("pack-%d", i)] = &concurrencyPack{mux: &mux, running: &running, max: &max}
+	}
+	packs, _ := m.Discover()
+	packs = append(packs, Pack{Name: "missing", Path: filepath.Join(t.TempDir(), "missing")})
+
+	results := m.RunAll(packs, RunRequest{})
+	for i, result := range results[:4] {
+		if result.Pack.Name != fmt.Sprintf("pack-%d", i) || result.Err != nil || result.Summary.ProbesPassed != 1 {
+			t.Errorf("Unexpected result: %+v", result)
+		}
+	}
+	if results[4].Err == nil || !strings.Contains(results[4].Err.Error(), "failed to start service pack 'missing'") {
+		t.Errorf("Expected an error for a missing pack, got %+v", results[4])
+	}
+	if max != 2 {
+		t.Errorf("Expected 2 packs to run at once, found %d", max)
+	}
+	if len(m.instances) != 0 {
+		t.Errorf("Expected every pack to be stopped, %d are running", len(m.instances))
+	}
+}
+
+func TestManager_RunAll_Events(t *testing.T) {
+	defer logging.UseLogger("default")
+	logging.UseLogger("manager-events-test", "DEBUG", ioutil.Discard, false)
+	var events []logging.Event
+	m := &Manager{
+		LogLevel:  "INFO",
+		InProcess: map[string]ServicePackV2{"events": &eventsPack{}},
+		Events: func(pack string, e logging.Event) {
+			if pack == "events" {
+				events = append(events, e)
+			}
+		},
+	}
+	packs, _ := m.Discover()
+	if results := m.RunAll(packs, RunRequest{}); results[0].Err != nil {
+		t.Fatalf("RunAll() error = %v", results[0].Err)
+	}
+	if len(events) != 2 || events[0].Type != logging.ProbeFinished || events[1].Type != logging.LogLine {
+		t.Errorf("Unexpected events: %+v", events)
+	}
+}
+
+// helperPackBinary writes a script to dir that runs this test binary as a service pack, and returns its checksum
+func helperPackBinary(t *testing.T, dir string) string {
+	if runtime.GOOS == "windows" {
+		t.Skip("The helper pack is started via a shell script")
+	}
+	script := fmt.Sprintf("#!/bin/sh\nPROBR_TEST_HELPER_PACK=1 exec %s -test.run=TestHelperPack\n", os.Args[0])
+	if err := ioutil.WriteFile(filepath.Join(dir, "helper"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256([]byte(script))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestManager_Start(t *testing.T) {
+	dir := t.TempDir()
+	checksum := helperPackBinary(t, dir)
+	m := &Manager{Dir: dir, Checksums: map[string]string{"helper": checksum}}
+	packs, err := m.Discover()
+	if err != nil || len(packs) != 1 {
+		t.Fatalf("Discover() = %+v, %v", packs, err)
+	}
+
+	instance, err := m.Start(packs[0])
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if info, err := instance.Info(); err != nil || info.Name != "test" {
+		t.Errorf("Info() = %+v, %v", info, err)
+	}
+	m.Close()
+	if _, err := instance.ListProbes(""); err == nil {
+		t.Errorf("Expected the pack to be stopped by Close")
+	}
+}
+
+func TestManager_Start_Checksums(t *testing.T) {
+	dir := t.TempDir()
+	helperPackBinary(t, dir)
+	pack := Pack{Name: "helper", Path: filepath.Join(dir, "helper")}
+
+	m := &Manager{RequireChecksums: true}
+	if _, err := m.Start(pack); err == nil || !strings.Contains(err.Error(), "no checksum") {
+		t.Errorf("Expected a missing checksum to be reported, got %v", err)
+	}
+	pack.Checksum = strings.Repeat("00", sha256.Size)
+	if _, err := m.Start(pack); err == nil || !strings.Contains(err.Error(), "checksums did not match") {
+		t.Errorf("Expected a mismatched checksum to be reported, got %v", err)
+	}
+}
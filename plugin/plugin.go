@@ -1,56 +1,238 @@
 package plugin
 
 import (
+	"fmt"
 	"net/rpc"
+	"strings"
+	"sync"
 
 	hcplugin "github.com/hashicorp/go-plugin"
+	"github.com/probr/probr-sdk/logging"
 )
 
 // ServicePack is the interface that we're exposing as a plugin.
+// New packs should implement ServicePackV2 instead, which allows probr core to list, configure and cancel probes.
 type ServicePack interface {
 	RunProbes() error
 }
 
-// ServicePackRPC is an implementation that talks over RPC
-type ServicePackRPC struct{ client *rpc.Client }
+// ServicePackV2 is the extended interface that we're exposing as a plugin
+type ServicePackV2 interface {
+	// Info describes the pack
+	Info() (PackInfo, error)
+	// ListProbes describes the probes that match a tag expression, or every probe if tags is empty
+	ListProbes(tags string) ([]ProbeInfo, error)
+	// Configure provides a vars file to be used by subsequent runs
+	Configure(config []byte) error
+	// RunProbes runs the requested probes and summarizes the results
+	RunProbes(request RunRequest) (*RunSummary, error)
+	// Cancel stops a run that is in progress
+	Cancel() error
+}
+
+// ServicePackClient is implemented by the host's clients for each protocol, as returned by Dispense.
+// Its RunProbes runs every probe, so that hosts written against ServicePack may still use the clients.
+type ServicePackClient interface {
+	ServicePack
+	// Info describes the pack
+	Info() (PackInfo, error)
+	// ListProbes describes the probes that match a tag expression, or every probe if tags is empty
+	ListProbes(tags string) ([]ProbeInfo, error)
+	// Configure provides a vars file to be used by subsequent runs
+	Configure(config []byte) error
+	// Run runs the requested probes via the pack's ServicePackV2.RunProbes and summarizes the results
+	Run(request RunRequest) (*RunSummary, error)
+	// Cancel stops a run that is in progress
+	Cancel() error
+	// RunProbesWithEvents runs the requested probes, passing the pack's progress events and any lines it logs
+	// at or above logLevel to handle as they occur. Packs that cannot stream events are run without them.
+	RunProbesWithEvents(request RunRequest, logLevel string, handle func(logging.Event)) (*RunSummary, error)
+}
+
+// ServicePackRPC is an implementation that talks over RPC.
+// It negotiates the API version with the pack, so that packs which only implement ServicePack may still be run.
+type ServicePackRPC struct {
+	client *rpc.Client
+
+	once sync.Once
+	info PackInfo
+	err  error
+}
+
+// Info returns the pack's description, or only its API version if the pack only implements ServicePack
+func (g *ServicePackRPC) Info() (PackInfo, error) {
+	g.once.Do(func() {
+		// The host's version is sent as a concrete value, as servers without Info cannot discard a nil interface
+		g.err = g.client.Call("Plugin.Info", APIVersion, &g.info)
+		if g.err != nil && strings.Contains(g.err.Error(), "can't find method") {
+			// Packs built against earlier versions of the SDK do not serve Info
+			g.info, g.err = PackInfo{APIVersion: 1}, nil
+		}
+		if g.err == nil {
+			g.info, g.err = negotiatedInfo(g.info)
+		}
+	})
+	return g.info, g.err
+}
 
-// RunProbes returns a message
+// ListProbes returns the probes that match a tag expression
+func (g *ServicePackRPC) ListProbes(tags string) ([]ProbeInfo, error) {
+	if err := g.require(CapabilityListProbes, "ListProbes"); err != nil {
+		return nil, err
+	}
+	var probes []ProbeInfo
+	err := g.client.Call("Plugin.ListProbes", tags, &probes)
+	return probes, err
+}
+
+// Configure provides a vars file to the pack
+func (g *ServicePackRPC) Configure(config []byte) error {
+	if err := g.require(CapabilityConfigure, "Configure"); err != nil {
+		return err
+	}
+	return g.client.Call("Plugin.Configure", config, new(interface{}))
+}
+
+// RunProbes runs every probe, returning an error if the run failed, as for hosts written against ServicePack
 func (g *ServicePackRPC) RunProbes() error {
-	var err error
-	return g.client.Call("Plugin.RunProbes", new(interface{}), &err)
+	return runError(g.Run(RunRequest{}))
+}
+
+// Run runs the requested probes. Packs that only implement ServicePack always run every probe,
+// so a request that selects probes returns an UnsupportedError.
+func (g *ServicePackRPC) Run(request RunRequest) (*RunSummary, error) {
+	info, err := g.Info()
+	if err != nil {
+		return nil, err
+	}
+	if (request.Tags != "" || len(request.Probes) > 0) && !info.Supports(CapabilitySelectProbes) {
+		return nil, &UnsupportedError{Method: "RunProbes with a RunRequest", APIVersion: info.APIVersion}
+	}
+	if info.APIVersion < 2 {
+		var runErr error
+		if err := g.client.Call("Plugin.RunProbes", new(interface{}), &runErr); err != nil {
+			return &RunSummary{ExitCode: 1, Status: err.Error()}, err
+		}
+		return &RunSummary{Status: "Complete"}, nil
+	}
+	summary := new(RunSummary)
+	err = g.client.Call("Plugin.Run", request, summary)
+	return summary, err
+}
+
+// RunProbesWithEvents runs the requested probes without events, which are only streamed over gRPC
+func (g *ServicePackRPC) RunProbesWithEvents(request RunRequest, logLevel string, handle func(logging.Event)) (*RunSummary, error) {
+	return g.Run(request)
+}
+
+// Cancel stops a run that is in progress
+func (g *ServicePackRPC) Cancel() error {
+	if err := g.require(CapabilityCancel, "Cancel"); err != nil {
+		return err
+	}
+	return g.client.Call("Plugin.Cancel", new(interface{}), new(interface{}))
+}
+
+// require returns an UnsupportedError if the pack does not advertise the capability
+func (g *ServicePackRPC) require(capability Capability, method string) error {
+	info, err := g.Info()
+	if err != nil {
+		return err
+	}
+	if !info.Supports(capability) {
+		return &UnsupportedError{Method: method, APIVersion: info.APIVersion}
+	}
+	return nil
 }
 
 // ServicePackRPCServer is the RPC server that ServicePackRPC talks to, conforming to
 // the requirements of net/rpc
 type ServicePackRPCServer struct {
-	// This is the real implementation
-	Impl ServicePack
+	// This is the real implementation; one of these should be set
+	Impl   ServicePack
+	ImplV2 ServicePackV2
 }
 
-// RunProbes is a wrapper for interface implementation
+// RunProbes is a wrapper for interface implementation, used by hosts built against earlier versions of the SDK
 func (s *ServicePackRPCServer) RunProbes(args interface{}, resp *error) error {
-	*resp = s.Impl.RunProbes()
+	if s.ImplV2 != nil {
+		*resp = runError(s.ImplV2.RunProbes(RunRequest{}))
+	} else {
+		*resp = s.Impl.RunProbes()
+	}
 	return *resp
 }
 
+// Info is a wrapper for interface implementation. The host provides its own API version.
+func (s *ServicePackRPCServer) Info(hostVersion int, resp *PackInfo) (err error) {
+	*resp, err = servedInfo(s.ImplV2, hostVersion)
+	return
+}
+
+// ListProbes is a wrapper for interface implementation
+func (s *ServicePackRPCServer) ListProbes(tags string, resp *[]ProbeInfo) (err error) {
+	if s.ImplV2 == nil {
+		return &UnsupportedError{Method: "ListProbes", APIVersion: 1}
+	}
+	*resp, err = s.ImplV2.ListProbes(tags)
+	return
+}
+
+// Configure is a wrapper for interface implementation
+func (s *ServicePackRPCServer) Configure(config []byte, resp *interface{}) error {
+	if s.ImplV2 == nil {
+		return &UnsupportedError{Method: "Configure", APIVersion: 1}
+	}
+	return s.ImplV2.Configure(config)
+}
+
+// Run is a wrapper for the interface implementation of RunProbes
+func (s *ServicePackRPCServer) Run(request RunRequest, resp *RunSummary) error {
+	if s.ImplV2 == nil {
+		return &UnsupportedError{Method: "RunProbes with a RunRequest", APIVersion: 1}
+	}
+	summary, err := s.ImplV2.RunProbes(request)
+	if summary != nil {
+		*resp = *summary
+	}
+	return err
+}
+
+// Cancel is a wrapper for interface implementation
+func (s *ServicePackRPCServer) Cancel(args interface{}, resp *interface{}) error {
+	if s.ImplV2 == nil {
+		return &UnsupportedError{Method: "Cancel", APIVersion: 1}
+	}
+	return s.ImplV2.Cancel()
+}
+
+// runError returns the error reported by ServicePack's RunProbes for the outcome of a run
+func runError(summary *RunSummary, err error) error {
+	if err == nil && summary != nil && summary.ExitCode != 0 {
+		err = fmt.Errorf("probes failed with exit code %d: %s", summary.ExitCode, summary.Status)
+	}
+	return err
+}
+
 // ServicePackPlugin is the implementation of plugin.Plugin so we can serve/consume this
 //
 // This has two methods: Server must return an RPC server for this plugin
-// type. We construct a GreeterRPCServer for this.
+// type. We construct a ServicePackRPCServer for this.
 //
 // Client must return an implementation of our interface that communicates
-// over an RPC client. We return GreeterRPC for this.
+// over an RPC client. We return ServicePackRPC for this, which implements ServicePackClient and so ServicePack.
 //
 // Ignore MuxBroker. That is used to create more multiplexed streams on our
 // plugin connection and is a more advanced use case.
 type ServicePackPlugin struct {
-	// Impl Injection
-	Impl ServicePack
+	// Impl Injection; one of these should be set when serving
+	Impl   ServicePack
+	ImplV2 ServicePackV2
 }
 
 // Server implements RPC server
 func (p *ServicePackPlugin) Server(*hcplugin.MuxBroker) (interface{}, error) {
-	return &ServicePackRPCServer{Impl: p.Impl}, nil
+	return &ServicePackRPCServer{Impl: p.Impl, ImplV2: p.ImplV2}, nil
 }
 
 // Client implements RPC client
@@ -0,0 +1,152 @@
+package plugin
+
+import (
+	"errors"
+	"net/rpc"
+	"strings"
+	"testing"
+
+	hcplugin "github.com/hashicorp/go-plugin"
+)
+
+type testPackV1 struct {
+	runs int
+}
+
+func (p *testPackV1) RunProbes() error {
+	p.runs++
+	return nil
+}
+
+type testPackV2 struct {
+	config    []byte
+	request   RunRequest
+	cancelled bool
+}
+
+func (p *testPackV2) Info() (PackInfo, error) {
+	return PackInfo{Name: "test", Version: "1.0.0", Tags: []string{"@test"}}, nil
+}
+
+func (p *testPackV2) ListProbes(tags string) ([]ProbeInfo, error) {
+	if tags == "invalid" {
+		return nil, errors.New("invalid tags")
+	}
+	return []ProbeInfo{{Name: "probe", Tags: []string{"@test"}}}, nil
+}
+
+func (p *testPackV2) Configure(config []byte) error {
+	p.config = config
+	return nil
+}
+
+func (p *testPackV2) RunProbes(request RunRequest) (*RunSummary, error) {
+	p.request = request
+	return &RunSummary{ExitCode: 1, Status: "Complete - 0/1 Succeeded", ProbesFailed: 1}, nil
+}
+
+func (p *testPackV2) Cancel() error {
+	p.cancelled = true
+	return nil
+}
+
+// dispense starts the plugin in-process and returns the client
+func dispense(t *testing.T, p hcplugin.Plugin) *ServicePackRPC {
+	client, _ := hcplugin.TestPluginRPCConn(t, map[string]hcplugin.Plugin{ServicePackPluginName: p}, nil)
+	t.Cleanup(func() { client.Close() })
+	raw, err := client.Dispense(ServicePackPluginName)
+	if err != nil {
+		t.Fatalf("Dispense() error = %v", err)
+	}
+	return raw.(*ServicePackRPC)
+}
+
+func TestServicePackRPC_V2(t *testing.T) {
+	pack := &testPackV2{}
+	sp := dispense(t, &ServicePackPlugin{ImplV2: pack})
+
+	info, err := sp.Info()
+	if err != nil || info.Name != "test" || info.APIVersion != APIVersion {
+		t.Errorf("Info() = %+v, %v", info, err)
+	}
+	probes, err := sp.ListProbes("@test")
+	if err != nil || len(probes) != 1 || probes[0].Name != "probe" {
+		t.Errorf("ListProbes() = %+v, %v", probes, err)
+	}
+	if _, err := sp.ListProbes("invalid"); err == nil || !strings.Contains(err.Error(), "invalid tags") {
+		t.Errorf("Expected the pack's error to be returned, got %v", err)
+	}
+	if err := sp.Configure([]byte("LogLevel: INFO")); err != nil || string(pack.config) != "LogLevel: INFO" {
+		t.Errorf("Configure() error = %v, config = %s", err, pack.config)
+	}
+	summary, err := sp.Run(RunRequest{Tags: "@test", Probes: []string{"probe"}})
+	if err != nil || summary.ExitCode != 1 || summary.ProbesFailed != 1 || pack.request.Tags != "@test" {
+		t.Errorf("RunProbes() = %+v, %v", summary, err)
+	}
+	if err := sp.Cancel(); err != nil || !pack.cancelled {
+		t.Errorf("Cancel() error = %v", err)
+	}
+}
+
+func TestServicePackRPC_V1(t *testing.T) {
+	pack := &testPackV1{}
+	sp := dispense(t, &ServicePackPlugin{Impl: pack})
+
+	if info, err := sp.Info(); err != nil || info.APIVersion != 1 {
+		t.Errorf("Info() = %+v, %v", info, err)
+	}
+	if _, err := sp.ListProbes(""); err == nil || !strings.Contains(err.Error(), "API version 1 does not support ListProbes") {
+		t.Errorf("Expected an UnsupportedError, got %v", err)
+	}
+	if _, err := sp.Run(RunRequest{Tags: "@test"}); err == nil {
+		t.Errorf("Expected an error when selecting probes from a version 1 pack")
+	}
+	if summary, err := sp.Run(RunRequest{}); err != nil || summary.ExitCode != 0 || pack.runs != 1 {
+		t.Errorf("RunProbes() = %+v, %v", summary, err)
+	}
+}
+
+// earlierServer serves only the methods provided by earlier versions of the SDK
+type earlierServer struct {
+	runs int
+}
+
+func (s *earlierServer) RunProbes(args interface{}, resp *error) error {
+	s.runs++
+	return nil
+}
+
+type earlierPlugin struct {
+	server *earlierServer
+}
+
+func (p *earlierPlugin) Server(*hcplugin.MuxBroker) (interface{}, error) {
+	return p.server, nil
+}
+
+func (earlierPlugin) Client(b *hcplugin.MuxBroker, c *rpc.Client) (interface{}, error) {
+	return &ServicePackRPC{client: c}, nil
+}
+
+func TestServicePackRPC_EarlierSDK(t *testing.T) {
+	server := &earlierServer{}
+	sp := dispense(t, &earlierPlugin{server: server})
+
+	if info, err := sp.Info(); err != nil || info.APIVersion != 1 {
+		t.Errorf("Info() = %+v, %v", info, err)
+	}
+	if _, err := sp.Run(RunRequest{}); err != nil || server.runs != 1 {
+		t.Errorf("Expected the legacy RunProbes to be called, error = %v", err)
+	}
+	if err := sp.RunProbes(); err != nil || server.runs != 2 {
+		t.Errorf("Expected RunProbes() to call the legacy RunProbes, error = %v", err)
+	}
+}
+
+func TestServicePackRPCServer_RunProbesForEarlierHosts(t *testing.T) {
+	server := &ServicePackRPCServer{ImplV2: &testPackV2{}}
+	var resp error
+	if err := server.RunProbes(nil, &resp); err == nil || !strings.Contains(err.Error(), "exit code 1") {
+		t.Errorf("Expected a failed run to be reported as an error, got %v", err)
+	}
+}
@@ -0,0 +1,135 @@
+// Package plugintest serves service packs in-process for testing, so that pack authors can run their probes
+// through the same clients used by probr core without building a binary or calling plugin.Serve, which never returns.
+package plugintest
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	hcplugin "github.com/hashicorp/go-plugin"
+	"github.com/probr/probr-sdk/audit"
+	"github.com/probr/probr-sdk/logging"
+	"github.com/probr/probr-sdk/plugin"
+)
+
+// Client is the host's client for a pack served by Start or StartGRPC. Its helpers fail the test on error.
+type Client struct {
+	plugin.ServicePackClient
+	t *testing.T
+}
+
+// Start serves the pack described by opts over net/rpc via go-plugin's test helpers, as for hosts built
+// against earlier versions of the SDK, and returns the host's client. The connection is closed when the test completes.
+func Start(t *testing.T, opts *plugin.ServeOpts) *Client {
+	t.Helper()
+	client, _ := hcplugin.TestPluginRPCConn(t, plugins(t, opts, plugin.NetRPCProtocolVersion), nil)
+	t.Cleanup(func() { client.Close() })
+	return dispense(t, client)
+}
+
+// StartGRPC serves the pack described by opts over gRPC, as for hosts that use plugin.NewClient
+func StartGRPC(t *testing.T, opts *plugin.ServeOpts) *Client {
+	t.Helper()
+	client, server := hcplugin.TestPluginGRPCConn(t, plugins(t, opts, plugin.GRPCProtocolVersion))
+	t.Cleanup(func() {
+		client.Close()
+		server.Stop()
+	})
+	return dispense(t, client)
+}
+
+func plugins(t *testing.T, opts *plugin.ServeOpts, protocolVersion int) hcplugin.PluginSet {
+	t.Helper()
+	if opts == nil || (opts.Pack == nil && opts.PackV2 == nil) {
+		t.Fatalf("Invalid (nil) plugin implementation provided")
+	}
+	return plugin.PluginSets(opts.Pack, opts.PackV2)[protocolVersion]
+}
+
+func dispense(t *testing.T, client hcplugin.ClientProtocol) *Client {
+	t.Helper()
+	raw, err := client.Dispense(plugin.ServicePackPluginName)
+	if err != nil {
+		t.Fatalf("Failed to dispense the service pack: %v", err)
+	}
+	return &Client{ServicePackClient: raw.(plugin.ServicePackClient), t: t}
+}
+
+// Run runs the requested probes and returns the summary, failing the test if the run returns an error.
+// Probes that fail do not fail the test; use ExpectResults to assert on them.
+func (c *Client) Run(request plugin.RunRequest) *plugin.RunSummary {
+	c.t.Helper()
+	summary, err := c.ServicePackClient.Run(request)
+	if err != nil {
+		c.t.Fatalf("Run() error = %v", err)
+	}
+	return summary
+}
+
+// RunWithEvents runs the requested probes as Run does, also returning the events published during the run and
+// the lines logged at or above logLevel. Events are only streamed by packs started via StartGRPC.
+func (c *Client) RunWithEvents(request plugin.RunRequest, logLevel string) (*plugin.RunSummary, []logging.Event) {
+	c.t.Helper()
+	var events []logging.Event
+	summary, err := c.RunProbesWithEvents(request, logLevel, func(e logging.Event) {
+		events = append(events, e)
+	})
+	if err != nil {
+		c.t.Fatalf("RunProbesWithEvents() error = %v", err)
+	}
+	return summary, events
+}
+
+// Probe returns the named probe's result from the summary, failing the test if the probe was not run
+func Probe(t *testing.T, summary *plugin.RunSummary, name string) plugin.ProbeResult {
+	t.Helper()
+	for _, probe := range summary.Probes {
+		if probe.Name == name {
+			return probe
+		}
+	}
+	t.Fatalf("Probe '%s' was not found in the summary", name)
+	return plugin.ProbeResult{}
+}
+
+// ExpectResults fails the test unless each named probe has the expected result, such as "Success" or "Failed"
+func ExpectResults(t *testing.T, summary *plugin.RunSummary, expected map[string]string) {
+	t.Helper()
+	for name, result := range expected {
+		if actual := Probe(t, summary, name).Result; actual != result {
+			t.Errorf("Probe '%s' result = '%s', Expected: '%s'", name, actual, result)
+		}
+	}
+}
+
+// ReadAudit reads the audit written for the probe, failing the test if it cannot be read
+func ReadAudit(t *testing.T, probe plugin.ProbeResult) *audit.Probe {
+	t.Helper()
+	data, err := ioutil.ReadFile(probe.AuditPath)
+	if err != nil {
+		t.Fatalf("Unable to read the audit for probe '%s': %v", probe.Name, err)
+	}
+	p := new(audit.Probe)
+	if err := json.Unmarshal(data, p); err != nil {
+		t.Fatalf("Unable to parse the audit for probe '%s': %v", probe.Name, err)
+	}
+	return p
+}
+
+// ExpectScenarios fails the test unless each named scenario in the audit has the expected result,
+// such as "Passed" or "Failed"
+func ExpectScenarios(t *testing.T, probe *audit.Probe, expected map[string]string) {
+	t.Helper()
+	actual := make(map[string]string)
+	for _, scenario := range probe.Scenarios {
+		actual[scenario.Name] = scenario.Result
+	}
+	for name, result := range expected {
+		if r, ok := actual[name]; !ok {
+			t.Errorf("Scenario '%s' was not found in the audit", name)
+		} else if r != result {
+			t.Errorf("Scenario '%s' result = '%s', Expected: '%s'", name, r, result)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package plugintest
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cucumber/godog"
+	"github.com/probr/probr-sdk/audit"
+	"github.com/probr/probr-sdk/config"
+	"github.com/probr/probr-sdk/logging"
+	"github.com/probr/probr-sdk/plugin"
+	"github.com/probr/probr-sdk/probeengine"
+)
+
+const feature = `Feature: Example
+  Scenario: Passing scenario
+    Given a step that passes
+
+  Scenario: Failing scenario
+    Given a step that passes
+    Then a step that fails
+`
+
+// exampleProbe runs the feature above, auditing each step automatically
+type exampleProbe struct {
+	path    string
+	summary *audit.SummaryState
+}
+
+func (p exampleProbe) Name() string                                { return "example" }
+func (p exampleProbe) Path() string                                { return p.path }
+func (p exampleProbe) ProbeInitialize(ctx *godog.TestSuiteContext) {}
+
+func (p exampleProbe) ScenarioInitialize(ctx *godog.ScenarioContext) {
+	probeengine.AuditScenarioContext(ctx, p.summary.GetProbeLog(p.Name()))
+	ctx.Step(`^a step that passes$`, func() error { return nil })
+	ctx.Step(`^a step that fails$`, func() error { return errors.New("step failed") })
+}
+
+// examplePack runs its probe via the probe engine, as a pack would
+type examplePack struct {
+	dir string
+}
+
+func (p *examplePack) Info() (plugin.PackInfo, error) {
+	return plugin.PackInfo{Name: "example", Version: "1.0.0"}, nil
+}
+
+func (p *examplePack) ListProbes(tags string) ([]plugin.ProbeInfo, error) {
+	return []plugin.ProbeInfo{{Name: "example"}}, nil
+}
+
+func (p *examplePack) Configure(config []byte) error {
+	return nil
+}
+
+func (p *examplePack) RunProbes(request plugin.RunRequest) (*plugin.RunSummary, error) {
+	c := &config.GlobalOpts{WriteDirectory: p.dir, GodogResultsFormat: "progress", LogLevel: "INFO"}
+	summary := audit.NewSummaryState("example")
+	summary.SetConfig(c)
+	store := probeengine.NewProbeStore("example", request.Tags, &summary)
+	store.Config = c
+
+	probe := exampleProbe{path: filepath.Join(p.dir, "example.feature"), summary: &summary}
+	code, err := store.RunAllProbes([]probeengine.Probe{probe})
+	return plugin.NewRunSummary(code, &summary), err
+}
+
+func (p *examplePack) Cancel() error {
+	return nil
+}
+
+func newExamplePack(t *testing.T) *examplePack {
+	dir := t.TempDir()
+	os.Mkdir(filepath.Join(dir, "cucumber"), 0755)
+	if err := ioutil.WriteFile(filepath.Join(dir, "example.feature"), []byte(feature), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return &examplePack{dir: dir}
+}
+
+func TestStart(t *testing.T) {
+	client := Start(t, &plugin.ServeOpts{PackV2: newExamplePack(t)})
+
+	if info, err := client.Info(); err != nil || info.Name != "example" {
+		t.Errorf("Info() = %+v, %v", info, err)
+	}
+	summary := client.Run(plugin.RunRequest{})
+	if summary.ExitCode == 0 || summary.ProbesFailed != 1 {
+		t.Errorf("Expected the failing scenario to fail the run: %+v", summary)
+	}
+	ExpectResults(t, summary, map[string]string{"example": "Failed"})
+
+	probe := ReadAudit(t, Probe(t, summary, "example"))
+	ExpectScenarios(t, probe, map[string]string{
+		"Passing scenario": "Passed",
+		"Failing scenario": "Failed",
+	})
+}
+
+func TestStartGRPC(t *testing.T) {
+	client := StartGRPC(t, &plugin.ServeOpts{PackV2: newExamplePack(t)})
+
+	summary, events := client.RunWithEvents(plugin.RunRequest{}, "")
+	ExpectResults(t, summary, map[string]string{"example": "Failed"})
+
+	finished := make(map[string]string)
+	for _, e := range events {
+		if e.Type == logging.ScenarioFinished {
+			finished[e.Scenario] = e.Result
+		}
+	}
+	if finished["Passing scenario"] != logging.ResultPassed || finished["Failing scenario"] != logging.ResultFailed {
+		t.Errorf("Unexpected scenario events: %+v", events)
+	}
+}
+
+// legacyPack only implements plugin.ServicePack
+type legacyPack struct {
+	runs int
+}
+
+func (p *legacyPack) RunProbes() error {
+	p.runs++
+	return nil
+}
+
+func TestStart_ServicePack(t *testing.T) {
+	pack := &legacyPack{}
+	client := Start(t, &plugin.ServeOpts{Pack: pack})
+
+	if summary := client.Run(plugin.RunRequest{}); summary.ExitCode != 0 || pack.runs != 1 {
+		t.Errorf("Run() = %+v, runs = %d", summary, pack.runs)
+	}
+}
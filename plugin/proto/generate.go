@@ -0,0 +1,4 @@
+// Package proto contains the protobuf definitions and generated gRPC code for the service pack API
+package proto
+
+//go:generate protoc --go_out=plugins=grpc,paths=source_relative:. servicepack.proto
@@ -1,8 +1,12 @@
 package plugin
 
 import (
+	"fmt"
 	"log"
 	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
 
 	hclog "github.com/hashicorp/go-hclog"
 	hcplugin "github.com/hashicorp/go-plugin"
@@ -17,6 +21,14 @@ const (
 	ServicePackPluginName = "servicepack"
 )
 
+const (
+	// NetRPCProtocolVersion is the plugin protocol version served over net/rpc, as used by hosts built
+	// against earlier versions of the SDK
+	NetRPCProtocolVersion = 1
+	// GRPCProtocolVersion is the plugin protocol version served over gRPC, as defined in plugin/proto
+	GRPCProtocolVersion = 2
+)
+
 // handshakeConfigs are used to just do a basic handshake between
 // a hcplugin and host. If the handshake fails, a user friendly error is shown.
 // This prevents users from executing bad hcplugins or executing a hcplugin
@@ -25,8 +37,9 @@ var handshakeConfig = GetHandshakeConfig()
 
 // ServeOpts are the configurations to serve a plugin.
 type ServeOpts struct {
-	//Interface implementation
-	Pack ServicePack
+	//Interface implementation; set one of these. PackV2 is used if both are set
+	Pack   ServicePack
+	PackV2 ServicePackV2
 
 	// Logger is the logger that go-plugin will use.
 	Logger hclog.Logger
@@ -51,28 +64,76 @@ func Serve(opts *ServeOpts) {
 
 	// Plugin implementation
 	// Guard Clause: Ensure plugin is not nil
-	if opts.Pack == nil {
+	if opts.Pack == nil && opts.PackV2 == nil {
 		log.Panic("Invalid (nil) plugin implementation provided")
 	}
-	spProbr := opts.Pack
-
-	// hcpluginMap is the map of hcplugins we can dispense.
-	var hcpluginMap = map[string]hcplugin.Plugin{
-		ServicePackPluginName: &ServicePackPlugin{Impl: spProbr},
-	}
 
 	hcplugin.Serve(&hcplugin.ServeConfig{
-		HandshakeConfig: handshakeConfig,
-		Plugins:         hcpluginMap,
-		Logger:          opts.Logger,
+		HandshakeConfig:  handshakeConfig,
+		VersionedPlugins: PluginSets(opts.Pack, opts.PackV2),
+		GRPCServer:       hcplugin.DefaultGRPCServer,
+		Logger:           opts.Logger,
 	})
 }
 
+// PluginSets returns the hcplugins we can dispense for each protocol version. go-plugin serves the newest version
+// supported by the host, so hosts built against earlier versions of the SDK continue to use net/rpc.
+// Hosts should pass PluginSets(nil, nil) to select the clients for each version.
+func PluginSets(pack ServicePack, packV2 ServicePackV2) map[int]hcplugin.PluginSet {
+	return map[int]hcplugin.PluginSet{
+		NetRPCProtocolVersion: {ServicePackPluginName: &ServicePackPlugin{Impl: pack, ImplV2: packV2}},
+		GRPCProtocolVersion:   {ServicePackPluginName: &ServicePackGRPCPlugin{Impl: pack, ImplV2: packV2}},
+	}
+}
+
+// NewClient creates a host-side client for the service pack run by cmd, using gRPC where the pack supports it.
+// The caller must Kill the client once it is finished with the pack.
+func NewClient(cmd *exec.Cmd, logger hclog.Logger) *hcplugin.Client {
+	return hcplugin.NewClient(clientConfig(cmd, logger))
+}
+
+func clientConfig(cmd *exec.Cmd, logger hclog.Logger) *hcplugin.ClientConfig {
+	return &hcplugin.ClientConfig{
+		HandshakeConfig:  handshakeConfig,
+		VersionedPlugins: PluginSets(nil, nil),
+		Cmd:              cmd,
+		AllowedProtocols: []hcplugin.Protocol{hcplugin.ProtocolNetRPC, hcplugin.ProtocolGRPC},
+		Logger:           logger,
+	}
+}
+
+// incompatibleProtocol matches the error returned by go-plugin when the pack serves no protocol version known to the host
+var incompatibleProtocol = regexp.MustCompile(`Incompatible API version with plugin\. Plugin version: (\d+)`)
+
+// Dispense starts the service pack if needed and returns its interface, over whichever protocol was negotiated.
+// A VersionError is returned if the pack only serves protocol versions that are unknown to this SDK.
+func Dispense(client *hcplugin.Client) (ServicePackClient, error) {
+	rpcClient, err := client.Client()
+	if match := incompatibleProtocol.FindStringSubmatch(fmt.Sprint(err)); match != nil {
+		version, _ := strconv.Atoi(match[1])
+		return nil, &VersionError{Protocol: true, Version: version, Min: NetRPCProtocolVersion, Max: GRPCProtocolVersion}
+	}
+	if err != nil {
+		return nil, err
+	}
+	raw, err := rpcClient.Dispense(ServicePackPluginName)
+	if err != nil {
+		return nil, err
+	}
+	pack, ok := raw.(ServicePackClient)
+	if !ok {
+		return nil, fmt.Errorf("plugin %s does not implement ServicePackClient: %T", ServicePackPluginName, raw)
+	}
+	return pack, nil
+}
+
 // GetHandshakeConfig provides handshake config details. It is used by core and service packs.
+// ProtocolVersion only applies to hosts that do not provide VersionedPlugins, such as those built against
+// earlier versions of the SDK; otherwise the version is negotiated from the keys of PluginSets.
 func GetHandshakeConfig() hcplugin.HandshakeConfig {
 
 	return hcplugin.HandshakeConfig{
-		ProtocolVersion:  1,
+		ProtocolVersion:  NetRPCProtocolVersion,
 		MagicCookieKey:   "PROBR_MAGIC_COOKIE",
 		MagicCookieValue: "probr.servicepack",
 	}
@@ -0,0 +1,91 @@
+package plugin
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	hcplugin "github.com/hashicorp/go-plugin"
+)
+
+// TestHelperPack is run as a service pack by the tests below, rather than as a test in its own right
+func TestHelperPack(t *testing.T) {
+	switch os.Getenv("PROBR_TEST_HELPER_PACK") {
+	case "1":
+		Serve(&ServeOpts{PackV2: &testPackV2{}, NoLogOutputOverride: true})
+	case "future":
+		// Serve as a pack built against a future SDK that has dropped the protocol versions supported by this one
+		hcplugin.Serve(&hcplugin.ServeConfig{
+			HandshakeConfig:  GetHandshakeConfig(),
+			VersionedPlugins: map[int]hcplugin.PluginSet{3: PluginSets(nil, &testPackV2{})[GRPCProtocolVersion]},
+			GRPCServer:       hcplugin.DefaultGRPCServer,
+		})
+	}
+}
+
+func helperPackCmd() *exec.Cmd {
+	return helperPackCmdFor("1")
+}
+
+func helperPackCmdFor(mode string) *exec.Cmd {
+	cmd := exec.Command(os.Args[0], "-test.run=TestHelperPack")
+	cmd.Env = append(os.Environ(), "PROBR_TEST_HELPER_PACK="+mode)
+	return cmd
+}
+
+func TestServe_GRPC(t *testing.T) {
+	client := NewClient(helperPackCmd(), nil)
+	defer client.Kill()
+
+	pack, err := Dispense(client)
+	if err != nil {
+		t.Fatalf("Dispense() error = %v", err)
+	}
+	if _, ok := pack.(*ServicePackGRPC); !ok || client.NegotiatedVersion() != GRPCProtocolVersion {
+		t.Errorf("Expected gRPC to be negotiated, got %T over protocol version %d", pack, client.NegotiatedVersion())
+	}
+	if info, err := pack.Info(); err != nil || info.Name != "test" {
+		t.Errorf("Info() = %+v, %v", info, err)
+	}
+}
+
+func TestServe_EarlierHost(t *testing.T) {
+	// Hosts built against earlier versions of the SDK only know the net/rpc plugin
+	client := hcplugin.NewClient(&hcplugin.ClientConfig{
+		HandshakeConfig: GetHandshakeConfig(),
+		Plugins:         map[string]hcplugin.Plugin{ServicePackPluginName: &ServicePackPlugin{}},
+		Cmd:             helperPackCmd(),
+	})
+	defer client.Kill()
+
+	rpcClient, err := client.Client()
+	if err != nil {
+		t.Fatalf("Client() error = %v", err)
+	}
+	raw, err := rpcClient.Dispense(ServicePackPluginName)
+	if err != nil {
+		t.Fatalf("Dispense() error = %v", err)
+	}
+	if _, ok := raw.(*ServicePackRPC); !ok || client.NegotiatedVersion() != NetRPCProtocolVersion {
+		t.Errorf("Expected net/rpc to be negotiated, got %T over protocol version %d", raw, client.NegotiatedVersion())
+	}
+	// Hosts written against ServicePack run every probe via the dispensed client, which reports the failed probe
+	if err := raw.(ServicePack).RunProbes(); err == nil || !strings.Contains(err.Error(), "exit code 1") {
+		t.Errorf("Expected RunProbes() to report the failed run, got %v", err)
+	}
+}
+
+func TestDispense_IncompatibleProtocol(t *testing.T) {
+	client := NewClient(helperPackCmdFor("future"), nil)
+	defer client.Kill()
+
+	var versionErr *VersionError
+	if _, err := Dispense(client); !errors.As(err, &versionErr) || !versionErr.Protocol || versionErr.Version != 3 {
+		t.Fatalf("Expected a VersionError for protocol version 3, got %v", err)
+	}
+	if !strings.Contains(versionErr.Error(), "too new") {
+		t.Errorf("Expected the pack to be reported as too new: %v", versionErr)
+	}
+}
@@ -0,0 +1,193 @@
+package plugin
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/probr/probr-sdk/audit"
+)
+
+// APIVersion is the version of the service pack API provided by this SDK.
+// Packs that only implement ServicePack report version 1.
+const APIVersion = 2
+
+const (
+	// MinAPIVersion is the oldest pack API version that hosts built against this SDK can run
+	MinAPIVersion = 1
+	// MinHostAPIVersion is the oldest host API version that packs built against this SDK can be run by
+	MinHostAPIVersion = 1
+)
+
+// Capability is an optional feature of the service pack API, advertised by packs via PackInfo
+type Capability string
+
+// Capabilities that may be advertised by packs
+const (
+	CapabilityListProbes   Capability = "list_probes"
+	CapabilityConfigure    Capability = "configure"
+	CapabilitySelectProbes Capability = "select_probes" // RunProbes honours the RunRequest
+	CapabilityCancel       Capability = "cancel"
+	CapabilityEvents       Capability = "events" // RunProbesWithEvents streams events, rather than only running the probes
+)
+
+// apiCapabilities returns the capabilities of packs that implement each API version but do not advertise any,
+// such as those built before capabilities were added
+func apiCapabilities(apiVersion int) []Capability {
+	if apiVersion < 2 {
+		return []Capability{}
+	}
+	return []Capability{CapabilityListProbes, CapabilityConfigure, CapabilitySelectProbes, CapabilityCancel}
+}
+
+// PackInfo describes a service pack
+type PackInfo struct {
+	Name       string
+	Version    string
+	Tags       []string // Tags that may be used to select the pack's probes
+	APIVersion int
+	// Capabilities are set by the SDK according to the API version and transport, unless set by the pack.
+	// Packs may set them to disable features that they do not support, such as CapabilityCancel.
+	Capabilities []Capability
+}
+
+// Supports returns whether the pack advertises the capability
+func (i PackInfo) Supports(c Capability) bool {
+	for _, capability := range i.Capabilities {
+		if capability == c {
+			return true
+		}
+	}
+	return false
+}
+
+// ProbeInfo describes a single probe within a service pack
+type ProbeInfo struct {
+	Name string
+	Tags []string
+}
+
+// RunRequest selects the probes to be run. Empty values run every probe in the pack.
+type RunRequest struct {
+	Tags   string   // A tag expression, as accepted by tags.Parse
+	Probes []string // Names of probes to run
+}
+
+// RunSummary is the outcome of running a service pack's probes
+type RunSummary struct {
+	ExitCode      int
+	Status        string
+	ProbesPassed  int
+	ProbesFailed  int
+	ProbesSkipped int
+	RiskScore     int
+	Probes        []ProbeResult
+}
+
+// ProbeResult is the outcome of a single probe
+type ProbeResult struct {
+	Name               string
+	Result             string
+	ScenariosAttempted int
+	ScenariosSucceeded int
+	ScenariosFailed    int
+	RiskScore          int
+	AuditPath          string
+	LogPath            string
+}
+
+// NewRunSummary creates a RunSummary from the state of a completed run, for packs implementing ServicePackV2
+func NewRunSummary(exitCode int, state *audit.SummaryState) *RunSummary {
+	summary := &RunSummary{
+		ExitCode:      exitCode,
+		Status:        state.Status,
+		ProbesPassed:  state.ProbesPassed,
+		ProbesFailed:  state.ProbesFailed,
+		ProbesSkipped: state.ProbesSkipped,
+		RiskScore:     state.RiskScore,
+	}
+	for name, probe := range state.Probes {
+		summary.Probes = append(summary.Probes, ProbeResult{
+			Name:               name,
+			Result:             probe.Result,
+			ScenariosAttempted: probe.ScenariosAttempted,
+			ScenariosSucceeded: probe.ScenariosSucceeded,
+			ScenariosFailed:    probe.ScenariosFailed,
+			RiskScore:          probe.RiskScore,
+			AuditPath:          probe.Path,
+			LogPath:            probe.LogPath,
+		})
+	}
+	sort.Slice(summary.Probes, func(i, j int) bool { return summary.Probes[i].Name < summary.Probes[j].Name })
+	return summary
+}
+
+// UnsupportedError is returned when a method is called on a pack built against an older version of the API
+type UnsupportedError struct {
+	Method     string
+	APIVersion int
+}
+
+func (e *UnsupportedError) Error() string {
+	if e.APIVersion >= APIVersion {
+		return fmt.Sprintf("service pack does not support %s", e.Method)
+	}
+	return fmt.Sprintf("service pack API version %d does not support %s; rebuild the pack against a newer probr-sdk", e.APIVersion, e.Method)
+}
+
+// VersionError is returned when a host and pack were built against incompatible versions of the SDK
+type VersionError struct {
+	Host     bool // Whether the host's version is unsupported, rather than the pack's
+	Protocol bool // Whether Version is a plugin protocol version, rather than an API version
+	Version  int
+	Min, Max int // The versions supported by the other party
+}
+
+func (e *VersionError) Error() string {
+	subject, kind, age := "service pack", "API", "old"
+	if e.Host {
+		subject = "host"
+	}
+	if e.Protocol {
+		kind = "plugin protocol"
+	}
+	if e.Version > e.Max {
+		age = "new"
+	}
+	advice := "rebuild the service pack against a newer probr-sdk"
+	if e.Host == (age == "old") {
+		advice = "upgrade probr core"
+	}
+	return fmt.Sprintf("%s %s version %d is too %s; versions %d to %d are supported: %s", subject, kind, e.Version, age, e.Min, e.Max, advice)
+}
+
+// servedInfo describes the pack to a host, advertising the capabilities of the pack and of the transport
+func servedInfo(impl ServicePackV2, hostVersion int, transport ...Capability) (PackInfo, error) {
+	if hostVersion < MinHostAPIVersion {
+		return PackInfo{}, &VersionError{Host: true, Version: hostVersion, Min: MinHostAPIVersion, Max: APIVersion}
+	}
+	if impl == nil {
+		return PackInfo{APIVersion: 1, Capabilities: apiCapabilities(1)}, nil
+	}
+	info, err := impl.Info()
+	if err != nil {
+		return PackInfo{}, err
+	}
+	info.APIVersion = APIVersion
+	if info.Capabilities == nil {
+		info.Capabilities = apiCapabilities(APIVersion)
+	}
+	info.Capabilities = append(info.Capabilities, transport...)
+	return info, nil
+}
+
+// negotiatedInfo checks the info returned by a pack, inferring its capabilities if it does not advertise any.
+// Packs with an API version outside MinAPIVersion to APIVersion are refused with a VersionError.
+func negotiatedInfo(info PackInfo) (PackInfo, error) {
+	if info.APIVersion < MinAPIVersion || info.APIVersion > APIVersion {
+		return info, &VersionError{Version: info.APIVersion, Min: MinAPIVersion, Max: APIVersion}
+	}
+	if len(info.Capabilities) == 0 {
+		info.Capabilities = apiCapabilities(info.APIVersion)
+	}
+	return info, nil
+}
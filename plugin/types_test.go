@@ -0,0 +1,80 @@
+package plugin
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestVersionError(t *testing.T) {
+	for _, test := range []struct {
+		err      VersionError
+		expected string
+	}{
+		{VersionError{Version: 0, Min: 1, Max: 2}, "service pack API version 0 is too old; versions 1 to 2 are supported: rebuild the service pack against a newer probr-sdk"},
+		{VersionError{Protocol: true, Version: 3, Min: 1, Max: 2}, "service pack plugin protocol version 3 is too new; versions 1 to 2 are supported: upgrade probr core"},
+		{VersionError{Host: true, Version: 1, Min: 2, Max: 3}, "host API version 1 is too old; versions 2 to 3 are supported: upgrade probr core"},
+		{VersionError{Host: true, Version: 4, Min: 2, Max: 3}, "host API version 4 is too new; versions 2 to 3 are supported: rebuild the service pack against a newer probr-sdk"},
+	} {
+		if actual := test.err.Error(); actual != test.expected {
+			t.Errorf("Error() = '%s', Expected: '%s'", actual, test.expected)
+		}
+	}
+}
+
+func TestServedInfo(t *testing.T) {
+	info, err := servedInfo(&testPackV2{}, APIVersion, CapabilityEvents)
+	if err != nil || info.APIVersion != APIVersion || !info.Supports(CapabilityCancel) || !info.Supports(CapabilityEvents) {
+		t.Errorf("servedInfo() = %+v, %v", info, err)
+	}
+	if info, _ := servedInfo(nil, APIVersion); info.APIVersion != 1 || len(info.Capabilities) != 0 {
+		t.Errorf("Expected packs that only implement ServicePack to advertise no capabilities, got %+v", info)
+	}
+	var versionErr *VersionError
+	if _, err := servedInfo(&testPackV2{}, MinHostAPIVersion-1); !errors.As(err, &versionErr) || !versionErr.Host {
+		t.Errorf("Expected hosts older than MinHostAPIVersion to be refused, got %v", err)
+	}
+}
+
+func TestNegotiatedInfo(t *testing.T) {
+	// Packs built before capabilities were added do not advertise them
+	info, err := negotiatedInfo(PackInfo{APIVersion: 2})
+	if err != nil || !info.Supports(CapabilityListProbes) || info.Supports(CapabilityEvents) {
+		t.Errorf("negotiatedInfo() = %+v, %v", info, err)
+	}
+	var versionErr *VersionError
+	if _, err := negotiatedInfo(PackInfo{APIVersion: MinAPIVersion - 1}); !errors.As(err, &versionErr) || versionErr.Host {
+		t.Errorf("Expected packs older than MinAPIVersion to be refused, got %v", err)
+	}
+	_, err = negotiatedInfo(PackInfo{APIVersion: APIVersion + 1})
+	if !errors.As(err, &versionErr) || versionErr.Host || versionErr.Version != APIVersion+1 || !strings.Contains(err.Error(), "too new") {
+		t.Errorf("Expected packs newer than APIVersion to be refused, got %v", err)
+	}
+}
+
+// limitedPack does not support cancelling a run
+type limitedPack struct {
+	testPackV2
+}
+
+func (p *limitedPack) Info() (PackInfo, error) {
+	return PackInfo{Name: "limited", Capabilities: []Capability{CapabilityListProbes, CapabilityConfigure, CapabilitySelectProbes}}, nil
+}
+
+func TestCapabilities(t *testing.T) {
+	for name, sp := range map[string]ServicePackClient{
+		"net/rpc": dispense(t, &ServicePackPlugin{ImplV2: &limitedPack{}}),
+		"gRPC":    dispenseGRPC(t, &ServicePackGRPCPlugin{ImplV2: &limitedPack{}}),
+	} {
+		info, err := sp.Info()
+		if err != nil || !info.Supports(CapabilityListProbes) || info.Supports(CapabilityCancel) {
+			t.Errorf("%s: Info() = %+v, %v", name, info, err)
+		}
+		if info.Supports(CapabilityEvents) != (name == "gRPC") {
+			t.Errorf("%s: Expected events to only be advertised over gRPC, got %+v", name, info.Capabilities)
+		}
+		if err := sp.Cancel(); err == nil || !strings.Contains(err.Error(), "service pack does not support Cancel") {
+			t.Errorf("%s: Expected an UnsupportedError, got %v", name, err)
+		}
+	}
+}
@@ -2,13 +2,15 @@ package probeengine
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"log"
 	"os"
 
 	"github.com/cucumber/godog"
 	"github.com/cucumber/godog/colors"
-	"github.com/probr/probr-sdk/config"
+
+	"github.com/probr/probr-sdk/logging"
 )
 
 // GodogProbeHandler is a wrapper to allow for multiple probe handlers in the future
@@ -17,7 +19,7 @@ func GodogProbeHandler(probe *GodogProbe) (int, *bytes.Buffer, error) {
 }
 
 func toFileGodogProbeHandler(gd *GodogProbe) (int, *bytes.Buffer, error) {
-	o, err := getOutputPath(gd.Name)
+	o, err := getOutputPath(gd.config().WriteDirectory, gd.Name)
 	if err != nil {
 		return -1, nil, err
 	}
@@ -52,18 +54,86 @@ func toFileGodogProbeHandler(gd *GodogProbe) (int, *bytes.Buffer, error) {
 
 func runTestSuite(o io.Writer, gd *GodogProbe) int {
 	opts := godog.Options{
-		Format: config.GlobalConfig.GodogResultsFormat,
+		Format: gd.config().GodogResultsFormat,
 		Output: colors.Colored(o),
 		Paths:  []string{gd.FeaturePath},
 		Tags:   gd.Tags,
 	}
 
+	previous := gd.fields.set(gd.logFields())
+	defer gd.fields.set(previous)
+
 	status := godog.TestSuite{
 		Name:                 gd.Name,
 		TestSuiteInitializer: gd.ProbeInitializer,
-		ScenarioInitializer:  gd.ScenarioInitializer,
-		Options:              &opts,
+		ScenarioInitializer: func(ctx *godog.ScenarioContext) {
+			scenarioHooks(ctx, gd.logFields(), gd.logFile, gd.fields)
+			if gd.ScenarioInitializer != nil {
+				gd.ScenarioInitializer(ctx)
+			}
+		},
+		Options: &opts,
 	}.Run()
 
 	return status
 }
+
+// scenarioHooks registers godog hooks that attach the current scenario and step to logs, publish their
+// progress as events, and begin a section for each scenario in the captured log file, if any
+func scenarioHooks(ctx *godog.ScenarioContext, probe logging.Fields, logFile io.Writer, run *runFields) {
+	// godog does not call AfterStep for steps that are undefined, or skipped after an earlier step failed,
+	// so their StepFinished events are published once the next step begins or the scenario finishes
+	var unfinished *godog.Step
+	var failed bool
+	finishStep := func() {
+		fields := run.current()
+		if unfinished != nil {
+			e := logging.Event{Type: logging.StepFinished, Fields: fields, Result: logging.ResultUndefined}
+			if failed {
+				e.Result = logging.ResultSkipped
+			}
+			logging.Publish(e)
+			unfinished, failed = nil, true // Steps after an undefined step are skipped
+		}
+		fields.Step = ""
+		run.set(fields)
+	}
+
+	ctx.BeforeScenario(func(s *godog.Scenario) {
+		if logFile != nil {
+			fmt.Fprintf(logFile, "\n=== Scenario: %s\n", s.Name)
+		}
+		fields := probe
+		fields.Scenario = s.Name
+		run.set(fields)
+		logging.Publish(logging.Event{Type: logging.ScenarioStarted, Fields: fields})
+	})
+	ctx.BeforeStep(func(st *godog.Step) {
+		finishStep()
+		unfinished = st
+		fields := run.current()
+		fields.Step = st.Text
+		run.set(fields)
+	})
+	ctx.AfterStep(func(st *godog.Step, err error) {
+		unfinished, failed = nil, failed || err != nil
+		fields := run.current()
+		logging.Publish(finishedEvent(logging.StepFinished, fields, err))
+		fields.Step = ""
+		run.set(fields)
+	})
+	ctx.AfterScenario(func(s *godog.Scenario, err error) {
+		finishStep()
+		logging.Publish(finishedEvent(logging.ScenarioFinished, run.current(), err))
+		run.set(probe)
+	})
+}
+
+// finishedEvent reports the result of a probe, scenario or step
+func finishedEvent(t logging.EventType, fields logging.Fields, err error) logging.Event {
+	e := logging.Event{Type: t, Fields: fields, Result: logging.ResultPassed}
+	if err != nil {
+		e.Result, e.Error = logging.ResultFailed, err.Error()
+	}
+	return e
+}
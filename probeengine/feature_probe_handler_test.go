@@ -0,0 +1,207 @@
+package probeengine
+
+import (
+	"errors"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cucumber/godog"
+	"github.com/probr/probr-sdk/audit"
+	"github.com/probr/probr-sdk/config"
+	"github.com/probr/probr-sdk/logging"
+)
+
+func TestRunTestSuite_LogFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fields.feature")
+	content := []byte(`Feature: Log fields
+  Scenario: Fields are set
+    Given a step that logs
+`)
+	if err := ioutil.WriteFile(path, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var fields logging.Fields
+	probe := &GodogProbe{
+		Name:        "fields",
+		Pack:        "pack",
+		RunID:       "run",
+		FeaturePath: path,
+		Config:      &config.GlobalOpts{GodogResultsFormat: "progress"},
+		ScenarioInitializer: func(ctx *godog.ScenarioContext) {
+			ctx.Step(`^a step that logs$`, func() error {
+				fields = logging.CurrentFields()
+				return nil
+			})
+		},
+	}
+	if status := runTestSuite(ioutil.Discard, probe); status != 0 {
+		t.Fatalf("runTestSuite() = %v, Expected: 0", status)
+	}
+
+	expected := logging.Fields{RunID: "run", Pack: "pack", Probe: "fields", Scenario: "Fields are set", Step: "a step that logs"}
+	if fields != expected {
+		t.Errorf("Fields during step = %+v, Expected: %+v", fields, expected)
+	}
+	if logging.CurrentFields() != (logging.Fields{}) {
+		t.Errorf("Fields should be restored after the run, found %+v", logging.CurrentFields())
+	}
+}
+
+func TestRunProbe_CapturesLogs(t *testing.T) {
+	dir := t.TempDir()
+	os.Mkdir(filepath.Join(dir, "cucumber"), 0755)
+	path := filepath.Join(dir, "capture.feature")
+	content := []byte(`Feature: Log capture
+  Scenario: Logs are captured
+    Given a step that logs
+`)
+	if err := ioutil.WriteFile(path, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	summary := audit.NewSummaryState("pack")
+	store := NewProbeStore("pack", "", &summary)
+	store.Config = &config.GlobalOpts{WriteDirectory: dir, GodogResultsFormat: "progress", LogLevel: "DEBUG"}
+	status := Pending
+	probe := &GodogProbe{
+		Name:        "capture",
+		FeaturePath: path,
+		Status:      &status,
+		Config:      store.Config,
+		ScenarioInitializer: func(ctx *godog.ScenarioContext) {
+			ctx.Step(`^a step that logs$`, func() error {
+				log.Printf("[DEBUG] Message from within the probe")
+				return nil
+			})
+		},
+	}
+	if _, err := store.RunProbe(probe); err != nil {
+		t.Fatalf("RunProbe() error = %v", err)
+	}
+	log.Printf("[DEBUG] Message after the probe")
+
+	logPath := summary.GetProbeLog("capture").LogPath
+	if logPath != filepath.Join(dir, "audit", "capture.log") {
+		t.Fatalf("Expected the log file to be referenced from the audit, found '%s'", logPath)
+	}
+	captured, _ := ioutil.ReadFile(logPath)
+	for _, expected := range []string{"=== Scenario: Logs are captured", "Message from within the probe", "step=\"a step that logs\""} {
+		if !strings.Contains(string(captured), expected) {
+			t.Errorf("Expected captured logs to contain '%s', found:\n%s", expected, captured)
+		}
+	}
+	if strings.Contains(string(captured), "Message after the probe") {
+		t.Errorf("Logs should not be captured after the probe completes")
+	}
+}
+
+func TestRunProbe_CapturesStoreLogs(t *testing.T) {
+	// Stores with their own loggers run at the same time, so each must only capture its own logs and fields
+	run := func(name string, done chan<- string) {
+		dir := t.TempDir()
+		os.Mkdir(filepath.Join(dir, "cucumber"), 0755)
+		path := filepath.Join(dir, name+".feature")
+		content := []byte("Feature: " + name + "\n  Scenario: " + name + " scenario\n    Given a step that logs\n    And a step that logs\n")
+		if err := ioutil.WriteFile(path, content, 0644); err != nil {
+			t.Error(err)
+		}
+
+		summary := audit.NewSummaryState(name)
+		store := NewProbeStore(name, "", &summary)
+		store.Config = &config.GlobalOpts{WriteDirectory: dir, GodogResultsFormat: "progress", LogLevel: "INFO"}
+		store.Logger = logging.NewLogger("INFO", ioutil.Discard, false)
+		status := Pending
+		probe := &GodogProbe{
+			Name:        name,
+			FeaturePath: path,
+			Status:      &status,
+			Config:      store.Config,
+			ScenarioInitializer: func(ctx *godog.ScenarioContext) {
+				ctx.Step(`^a step that logs$`, func() error {
+					store.ProbeLogger().Info("message from " + name)
+					log.Printf("[INFO] process-wide message from %s", name)
+					return nil
+				})
+			},
+		}
+		if _, err := store.RunProbe(probe); err != nil {
+			t.Error(err)
+		}
+		captured, _ := ioutil.ReadFile(summary.GetProbeLog(name).LogPath)
+		done <- string(captured)
+	}
+
+	first, second := make(chan string, 1), make(chan string, 1)
+	go run("first", first)
+	go run("second", second)
+	for name, captured := range map[string]string{"first": <-first, "second": <-second} {
+		other := map[string]string{"first": "second", "second": "first"}[name]
+		if !strings.Contains(captured, "message from "+name) || !strings.Contains(captured, "scenario=\""+name+" scenario\"") {
+			t.Errorf("Expected the %s store's logs and fields to be captured, found:\n%s", name, captured)
+		}
+		if strings.Contains(captured, other) || strings.Contains(captured, "process-wide") {
+			t.Errorf("Expected only the %s store's logs to be captured, found:\n%s", name, captured)
+		}
+	}
+	if logging.CurrentFields() != (logging.Fields{}) {
+		t.Errorf("Stores with their own logger should not set process-wide fields, found %+v", logging.CurrentFields())
+	}
+}
+
+func TestRunTestSuite_PublishesEvents(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.feature")
+	content := []byte(`Feature: Events
+  Scenario: Events are published
+    Given a step that passes
+    Then a step that fails
+    And a step that passes
+
+  Scenario: Undefined steps
+    Given a step that is undefined
+    Then a step that passes
+`)
+	if err := ioutil.WriteFile(path, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var events []logging.Event
+	unsubscribe := logging.Subscribe(func(e logging.Event) { events = append(events, e) }, "")
+	defer unsubscribe()
+
+	probe := &GodogProbe{
+		Name:        "events",
+		FeaturePath: path,
+		Config:      &config.GlobalOpts{GodogResultsFormat: "progress"},
+		ScenarioInitializer: func(ctx *godog.ScenarioContext) {
+			ctx.Step(`^a step that passes$`, func() error { return nil })
+			ctx.Step(`^a step that fails$`, func() error { return errors.New("step failed") })
+		},
+	}
+	runTestSuite(ioutil.Discard, probe)
+
+	expected := []logging.Event{
+		{Type: logging.ScenarioStarted, Fields: logging.Fields{Probe: "events", Scenario: "Events are published"}},
+		{Type: logging.StepFinished, Fields: logging.Fields{Probe: "events", Scenario: "Events are published", Step: "a step that passes"}, Result: logging.ResultPassed},
+		{Type: logging.StepFinished, Fields: logging.Fields{Probe: "events", Scenario: "Events are published", Step: "a step that fails"}, Result: logging.ResultFailed, Error: "step failed"},
+		{Type: logging.StepFinished, Fields: logging.Fields{Probe: "events", Scenario: "Events are published", Step: "a step that passes"}, Result: logging.ResultSkipped},
+		{Type: logging.ScenarioFinished, Fields: logging.Fields{Probe: "events", Scenario: "Events are published"}, Result: logging.ResultFailed, Error: "step failed"},
+		{Type: logging.ScenarioStarted, Fields: logging.Fields{Probe: "events", Scenario: "Undefined steps"}},
+		{Type: logging.StepFinished, Fields: logging.Fields{Probe: "events", Scenario: "Undefined steps", Step: "a step that is undefined"}, Result: logging.ResultUndefined},
+		{Type: logging.StepFinished, Fields: logging.Fields{Probe: "events", Scenario: "Undefined steps", Step: "a step that passes"}, Result: logging.ResultSkipped},
+		{Type: logging.ScenarioFinished, Fields: logging.Fields{Probe: "events", Scenario: "Undefined steps"}, Result: logging.ResultFailed, Error: godog.ErrUndefined.Error()},
+	}
+	if len(events) != len(expected) {
+		t.Fatalf("Expected %d events, got %+v", len(expected), events)
+	}
+	for i := range expected {
+		events[i].Time = expected[i].Time
+		if events[i] != expected[i] {
+			t.Errorf("Event %d = %+v, Expected: %+v", i, events[i], expected[i])
+		}
+	}
+}
@@ -27,20 +27,30 @@ var getTmpFeatureFileFunc = getTmpFeatureFile // See TestGetFeaturePath
 
 // getOutputPath gets the output path for the test based on the output directory
 // plus the test name supplied
-func getOutputPath(name string) (*os.File, error) {
+func getOutputPath(writeDirectory, name string) (*os.File, error) {
 	filename := name + ".json"
 	return os.Create(filepath.Join(
-		config.GlobalConfig.WriteDirectory, "cucumber", filename))
+		writeDirectory, "cucumber", filename))
 }
 
-// GetFilePath parses a list of strings into a standardized file path. The filename should be in the final element of path
-func GetFilePath(path ...string) (filePath string) {
+// GetFilePath parses a list of strings into a standardized file path. The filename should be in the final element of path.
+// The file is unpacked to config.GlobalConfig.TmpDir; use ProbeStore.GetFilePath to use the store's config.
+func GetFilePath(path ...string) string {
+	return getFilePath(config.GlobalConfig.TmpDir, path...)
+}
+
+// GetFilePath parses a list of strings into a standardized file path, unpacked to the store's TmpDir
+func (ps *ProbeStore) GetFilePath(path ...string) string {
+	return getFilePath(ps.config().TmpDir, path...)
+}
+
+func getFilePath(tmpDir string, path ...string) (filePath string) {
 	for _, entry := range path {
 		filePath = filepath.Join(filePath, entry)
 	}
 
 	// Unpacking/copying feature file to tmp location
-	tmpFilePath, err := getTmpFeatureFileFunc(filePath)
+	tmpFilePath, err := getTmpFeatureFileFunc(tmpDir, filePath)
 	if err != nil {
 		log.Printf("Error unpacking feature file '%v' - Error: %v", filePath, err)
 		return ""
@@ -48,7 +58,8 @@ func GetFilePath(path ...string) (filePath string) {
 	return tmpFilePath
 }
 
-// GetFeaturePath parses a list of strings into a standardized file path for the BDD ".feature" files
+// GetFeaturePath parses a list of strings into a standardized file path for the BDD ".feature" files.
+// The file is unpacked to config.GlobalConfig.TmpDir; use ProbeStore.GetFeaturePath to use the store's config.
 // TODO: refactor this to use GetFilePath
 func GetFeaturePath(path ...string) string {
 	featureName := path[len(path)-1] + ".feature"
@@ -56,11 +67,18 @@ func GetFeaturePath(path ...string) string {
 	return GetFilePath(path...)
 }
 
+// GetFeaturePath parses a list of strings into a standardized file path for the BDD ".feature" files, unpacked to the store's TmpDir
+func (ps *ProbeStore) GetFeaturePath(path ...string) string {
+	featureName := path[len(path)-1] + ".feature"
+	path = append(path, featureName)
+	return ps.GetFilePath(path...)
+}
+
 // getTmpFeatureFile checks if feature file exists in -tmp- folder.
 // If so returns the file path, otherwise unpacks the original file using pkger and copies it to -tmp- location before returning file path.
-func getTmpFeatureFile(featurePath string) (string, error) {
+func getTmpFeatureFile(tmpDir, featurePath string) (string, error) {
 
-	tmpFeaturePath := filepath.Join(config.GlobalConfig.TmpDir, featurePath)
+	tmpFeaturePath := filepath.Join(tmpDir, featurePath)
 
 	// If file already exists return it
 	_, e := os.Stat(tmpFeaturePath)
@@ -18,8 +18,7 @@ func TestMain(m *testing.M) {
 	os.MkdirAll(filepath.Join(testFolder()), 0755)
 
 	defer func() {
-		os.RemoveAll(testFolder())
-		os.RemoveAll(config.GlobalConfig.TmpDir) // Delete test data after tests
+		os.RemoveAll(config.GlobalConfig.TmpDir) // Delete test data after tests; testdata is tracked and must be kept
 	}()
 	m.Run()
 }
@@ -49,7 +48,7 @@ func TestGetOutputPath(t *testing.T) {
 		t.Error(err)
 	}
 
-	file, _ = getOutputPath(f)
+	file, _ = getOutputPath(config.GlobalConfig.WriteDirectory, f)
 	if desiredFile != file.Name() {
 		t.Logf("Desired filepath '%s' does not match '%s'", desiredFile, file.Name())
 		t.Fail()
@@ -78,7 +77,7 @@ func TestScenarioString(t *testing.T) {
 
 func TestGetFeaturePath(t *testing.T) {
 	// Faking result for getTmpFeatureFileFunc() to avoid creating -tmp- folder and feature file.
-	getTmpFeatureFileFunc = func(featurePath string) (string, error) {
+	getTmpFeatureFileFunc = func(tmpDir, featurePath string) (string, error) {
 		tmpFeaturePath := filepath.Join("tmp", featurePath)
 		return tmpFeaturePath, nil
 	}
@@ -109,11 +108,29 @@ func TestGetFeaturePath(t *testing.T) {
 	}
 }
 
+func TestProbeStore_GetFeaturePath(t *testing.T) {
+	var unpackedTo string
+	getTmpFeatureFileFunc = func(tmpDir, featurePath string) (string, error) {
+		unpackedTo = tmpDir
+		return filepath.Join(tmpDir, featurePath), nil
+	}
+	defer func() {
+		getTmpFeatureFileFunc = getTmpFeatureFile
+	}()
+
+	ps := NewProbeStore("test", "", nil)
+	ps.Config = &config.GlobalOpts{TmpDir: filepath.Join("store", "tmp")}
+	expected := filepath.Join("store", "tmp", "probes", "example", "example.feature")
+	if got := ps.GetFeaturePath("probes", "example"); got != expected || unpackedTo != ps.Config.TmpDir {
+		t.Errorf("GetFeaturePath() = %v, Expected: %v", got, expected)
+	}
+}
+
 func Test_getTmpFeatureFile(t *testing.T) {
-	config.GlobalConfig.TmpDir = t.TempDir()
+	tmpDir := t.TempDir()
 	filename := "Test_getTmpFeatureFile.feature"
 	os.Create(filepath.Join(testFolder(), filename))
-	os.MkdirAll(filepath.Join(config.GlobalConfig.TmpDir, "probeengine", "testdata"), 0755)
+	os.MkdirAll(filepath.Join(tmpDir, "probeengine", "testdata"), 0755)
 
 	tests := []struct {
 		testName       string
@@ -124,13 +141,13 @@ func Test_getTmpFeatureFile(t *testing.T) {
 		{
 			testName:       "ShouldCreateTmpFolderWithFeatureFile",
 			featurePath:    filepath.Join("probeengine", "testdata", filename), // This cannot be an absolute path, since it will be joined with temp dir
-			expectedResult: filepath.Join(config.GlobalConfig.TmpDir, "probeengine", "testdata", filename),
+			expectedResult: filepath.Join(tmpDir, "probeengine", "testdata", filename),
 			expectedErr:    false,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.testName, func(t *testing.T) {
-			got, err := getTmpFeatureFile(tt.featurePath)
+			got, err := getTmpFeatureFile(tmpDir, tt.featurePath)
 			if err != nil {
 				t.Error(err)
 			}
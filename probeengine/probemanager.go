@@ -8,7 +8,10 @@ import (
 	"log"
 	"sync"
 
+	hclog "github.com/hashicorp/go-hclog"
 	audit "github.com/probr/probr-sdk/audit"
+	"github.com/probr/probr-sdk/config"
+	"github.com/probr/probr-sdk/logging"
 )
 
 // ProbeStatus type describes the status of the test, e.g. Pending, Running, CompleteSuccess, CompleteFail and Error
@@ -37,6 +40,11 @@ type ProbeStore struct {
 	Lock         sync.RWMutex
 	Summary      *audit.SummaryState
 	Tags         string
+	Config       *config.GlobalOpts // Defaults to config.GlobalConfig
+	Logger       hclog.Logger       // Defaults to the active logger from the logging package, see ProbeLogger
+	RunID        string             // Attached to logs from each probe, see logging.Fields
+	fields       *runFields
+	fieldsOnce   sync.Once
 }
 
 // NewProbeStore creates a new object to store GodogProbes
@@ -47,9 +55,24 @@ func NewProbeStore(name string, tags string, summaryState *audit.SummaryState) *
 		Probes:  make(map[string]*GodogProbe),
 		Summary: summaryState,
 		Tags:    tags,
+		RunID:   logging.NewRunID(),
 	}
 }
 
+func (ps *ProbeStore) config() *config.GlobalOpts {
+	if ps.Config == nil {
+		return &config.GlobalConfig
+	}
+	return ps.Config
+}
+
+func (ps *ProbeStore) logger() hclog.Logger {
+	if ps.Logger == nil {
+		return logging.Logger()
+	}
+	return ps.Logger
+}
+
 // RunAllProbes retrieves and executes all probes that have been included
 func (ps *ProbeStore) RunAllProbes(probes []Probe) (int, error) {
 	for _, probe := range probes {
@@ -106,12 +129,14 @@ func (ps *ProbeStore) ExecAllProbes() (int, error) {
 	var err error
 
 	for name := range ps.Probes {
+		ps.Summary.ProbeStarted(name)
 		st, err := ps.ExecProbe(name)
 		ps.Summary.ProbeComplete(name)
 		if err != nil {
 			//log but continue with remaining probe
-			log.Printf("[ERROR] error executing probe: %v", err)
+			ps.logger().Error("error executing probe", "probe", name, "error", err)
 		}
+		st = ps.applyExitSeverity(name, st, err)
 		if st > status {
 			status = st
 		}
@@ -120,6 +145,24 @@ func (ps *ProbeStore) ExecAllProbes() (int, error) {
 	return status, err
 }
 
+// applyExitSeverity lowers a probe's failure status if the user has configured a minimum failure severity
+// and every failed scenario in the probe is below it. Any other non-zero status, such as a probe error,
+// a missing feature file or a failure that was not audited, is kept.
+func (ps *ProbeStore) applyExitSeverity(name string, status int, err error) int {
+	if status != 1 || err != nil || ps.config().ExitSeverity == "" {
+		return status
+	}
+	minimum, parseErr := audit.ParseSeverity(ps.config().ExitSeverity)
+	if parseErr != nil {
+		return status // Rejected by config validation during Init
+	}
+	probe := ps.Summary.GetProbeLog(name)
+	if probe.ScenariosFailed > 0 && probe.HighestFailedSeverity() < minimum {
+		return 0
+	}
+	return status
+}
+
 func (ps *ProbeStore) makeGodogProbe(pack string, probe Probe) *GodogProbe {
 	return &GodogProbe{
 		Name:                probe.Name(),
@@ -128,5 +171,7 @@ func (ps *ProbeStore) makeGodogProbe(pack string, probe Probe) *GodogProbe {
 		ScenarioInitializer: probe.ScenarioInitialize,
 		FeaturePath:         probe.Path(),
 		Tags:                ps.Tags,
+		Config:              ps.config(),
+		RunID:               ps.RunID,
 	}
 }
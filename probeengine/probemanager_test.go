@@ -1,7 +1,14 @@
 package probeengine
 
 import (
+	"errors"
+	"io/ioutil"
+	"testing"
+
 	"github.com/cucumber/godog"
+	"github.com/cucumber/messages-go/v10"
+	"github.com/probr/probr-sdk/audit"
+	"github.com/probr/probr-sdk/config"
 )
 
 const (
@@ -33,3 +40,38 @@ func (probe TestProbe) ScenarioInitialize(ctx *godog.ScenarioContext) {
 }
 
 // TODO: Add tests for ProbeStore
+
+func TestProbeStore_applyExitSeverity(t *testing.T) {
+	summary := audit.NewSummaryState("test")
+	summary.AddSink(&audit.StdoutSink{Writer: ioutil.Discard})
+	for name, severity := range map[string]string{"low": "@severity-low", "high": "@severity-high"} {
+		p := summary.GetProbeLog(name)
+		p.InitializeAuditor("failing", []*messages.Pickle_PickleTag{{Name: severity}}).AuditScenarioThen("then", "", nil, errors.New("failed"))
+		summary.ProbeComplete(name)
+	}
+	summary.ProbeComplete("unaudited")
+
+	tests := []struct {
+		name     string
+		probe    string
+		status   int
+		err      error
+		expected int
+	}{
+		{"Failures below the minimum should pass", "low", 1, nil, 0},
+		{"Failures at or above the minimum should fail", "high", 1, nil, 1},
+		{"Failures that were not audited should fail", "unaudited", 1, nil, 1},
+		{"Probe errors should be kept", "low", 1, errors.New("probe error"), 1},
+		{"Invalid options should be kept", "low", 2, nil, 2},
+		{"Successes should pass", "high", 0, nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ps := NewProbeStore("test", "", &summary)
+			ps.Config = &config.GlobalOpts{ExitSeverity: "high"}
+			if got := ps.applyExitSeverity(tt.probe, tt.status, tt.err); got != tt.expected {
+				t.Errorf("applyExitSeverity() = %d, Expected: %d", got, tt.expected)
+			}
+		})
+	}
+}
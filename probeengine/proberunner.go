@@ -3,11 +3,16 @@ package probeengine
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"path/filepath"
+	"sync"
 
 	"github.com/cucumber/godog"
+	hclog "github.com/hashicorp/go-hclog"
 	"github.com/probr/probr-sdk/config"
+	"github.com/probr/probr-sdk/logging"
 )
 
 // ProbeRunner describes the interface that should be implemented to support the execution of tests.
@@ -30,6 +35,49 @@ type GodogProbe struct {
 	Status              *ProbeStatus
 	Results             *bytes.Buffer
 	Tags                string
+	Config              *config.GlobalOpts // Defaults to config.GlobalConfig
+	RunID               string
+	logFile             io.Writer  // Receives a heading for each scenario while logs are captured
+	fields              *runFields // Set while run by a store with its own Logger; otherwise fields are process-wide
+}
+
+// runFields holds the fields identifying the work being run by a store with its own Logger, in place of those set
+// process-wide via logging.SetFields, so that stores running at the same time do not overwrite each other's fields.
+// A nil runFields uses the process-wide fields.
+type runFields struct {
+	mux    sync.Mutex
+	fields logging.Fields
+}
+
+func (r *runFields) set(f logging.Fields) (previous logging.Fields) {
+	if r == nil {
+		return logging.SetFields(f)
+	}
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	previous, r.fields = r.fields, f
+	return
+}
+
+func (r *runFields) current() logging.Fields {
+	if r == nil {
+		return logging.CurrentFields()
+	}
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	return r.fields
+}
+
+// logFields identifies the probe in logs, see logging.SetFields
+func (gd *GodogProbe) logFields() logging.Fields {
+	return logging.Fields{RunID: gd.RunID, Pack: gd.Pack, Probe: gd.Name}
+}
+
+func (gd *GodogProbe) config() *config.GlobalOpts {
+	if gd.Config == nil {
+		return &config.GlobalConfig
+	}
+	return gd.Config
 }
 
 // RunProbe runs the test cases described by the supplied Probe
@@ -40,20 +88,79 @@ func (ps *ProbeStore) RunProbe(probe *GodogProbe) (int, error) {
 		return 2, fmt.Errorf("probe is nil - cannot run test")
 	}
 
+	logging.Publish(logging.Event{Type: logging.ProbeStarted, Fields: probe.logFields()})
+	probe.fields = ps.runFields()
+	stopCapture := ps.captureLogs(probe)
 	s, o, err := GodogProbeHandler(probe)
+	stopCapture()
+	probe.fields = nil
 
+	finished := finishedEvent(logging.ProbeFinished, probe.logFields(), err)
 	if s == 0 {
 		// success
 		*probe.Status = CompleteSuccess
 	} else {
 		// fail
 		*probe.Status = CompleteFail
+		finished.Result = logging.ResultFailed
 	}
+	logging.Publish(finished)
 
 	probe.Results = o // If in-mem output provided, store as Results
 	return s, err
 }
 
+// captureLogs copies the logs emitted while the probe runs to audit/<probe>.log, and references the file from
+// the probe's audit. Logs continue to be written to the active logger. Stores with their own Logger only capture
+// logs written via that logger, as other stores may be logging via the active logger at the same time.
+func (ps *ProbeStore) captureLogs(probe *GodogProbe) (stop func()) {
+	path := filepath.Join(ps.config().WriteDirectory, "audit", probe.Name+".log")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		ps.logger().Warn("Unable to capture logs", "probe", probe.Name, "error", err)
+		return func() {}
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		ps.logger().Warn("Unable to capture logs", "probe", probe.Name, "error", err)
+		return func() {}
+	}
+	ps.Summary.GetProbeLog(probe.Name).LogPath = path
+	probe.logFile = f
+	var stopCapture func()
+	if ps.ownLogger() {
+		stopCapture = logging.CaptureLogger(ps.Logger, f, ps.config().LogLevel)
+	} else {
+		stopCapture = logging.Capture(f, ps.config().LogLevel)
+	}
+	return func() {
+		stopCapture()
+		probe.logFile = nil
+		f.Close()
+	}
+}
+
+// ownLogger reports whether the store logs via its own Logger rather than the active logger
+func (ps *ProbeStore) ownLogger() bool {
+	return ps.Logger != nil && ps.Logger != logging.Logger()
+}
+
+// runFields returns the fields of a store with its own Logger, or nil if it uses the process-wide fields
+func (ps *ProbeStore) runFields() *runFields {
+	if !ps.ownLogger() {
+		return nil
+	}
+	ps.fieldsOnce.Do(func() { ps.fields = new(runFields) })
+	return ps.fields
+}
+
+// ProbeLogger returns the store's logger with the fields identifying the probe, scenario and step being run,
+// as logging.ProbeLogger does for the active logger. Stores with their own Logger, such as those created via
+// sdk.Runtime, keep these fields with the store rather than process-wide, and only capture the logs written via
+// their Logger, so their probes should log via ProbeLogger rather than log.Printf or logging.ProbeLogger.
+func (ps *ProbeStore) ProbeLogger() hclog.Logger {
+	return logging.WithFields(ps.logger(), ps.runFields().current())
+}
+
 // GetAllProbeResults maps ProbeStore results to strings
 // Designed for use with in-memory output, such as for an API runtime
 func GetAllProbeResults(ps *ProbeStore) (allResults map[string]string) {
@@ -79,9 +186,19 @@ func readProbeResults(ps *ProbeStore, name string) (probeResults, probeName stri
 	return
 }
 
-// CleanupTmp is used to dispose of any temp resources used during execution
+// CleanupTmp is used to dispose of any temp resources used during execution in config.GlobalConfig.TmpDir.
+// Use ProbeStore.CleanupTmp to use the store's config.
 func CleanupTmp() {
-	err := os.RemoveAll(config.GlobalConfig.TmpDir)
+	cleanupTmp(config.GlobalConfig.TmpDir)
+}
+
+// CleanupTmp disposes of any temp resources used during execution with the store's config
+func (ps *ProbeStore) CleanupTmp() {
+	cleanupTmp(ps.config().TmpDir)
+}
+
+func cleanupTmp(dir string) {
+	err := os.RemoveAll(dir)
 	if err != nil {
 		log.Printf("[ERROR] Error removing tmp folder %v", err)
 	}
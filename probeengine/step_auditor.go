@@ -0,0 +1,80 @@
+package probeengine
+
+import (
+	"log"
+
+	"github.com/cucumber/godog"
+	"github.com/cucumber/messages-go/v10"
+
+	"github.com/probr/probr-sdk/audit"
+)
+
+// ScenarioAuditor audits every step of a scenario automatically via godog step hooks
+type ScenarioAuditor struct {
+	probe    *audit.Probe
+	pickle   *godog.Scenario
+	scenario *audit.Scenario
+}
+
+// AuditScenarioContext is an opt-in helper that registers godog hooks to audit each step's text, keyword,
+// argument, error and duration. It should be called from a probe's ScenarioInitialize function.
+// Step functions may still use AuditScenarioStep on the returned auditor's Scenario() to add a payload.
+// Steps that are skipped after a failure, or have no step definition, are audited as "Skipped" or "Undefined".
+func AuditScenarioContext(ctx *godog.ScenarioContext, probe *audit.Probe) *ScenarioAuditor {
+	a := &ScenarioAuditor{probe: probe}
+	ctx.BeforeScenario(a.beforeScenario)
+	ctx.BeforeStep(a.beforeStep)
+	ctx.AfterStep(a.afterStep)
+	ctx.AfterScenario(a.afterScenario)
+	return a
+}
+
+// Scenario returns the audit entry for the scenario currently being executed
+func (a *ScenarioAuditor) Scenario() *audit.Scenario {
+	return a.scenario
+}
+
+func (a *ScenarioAuditor) beforeScenario(s *godog.Scenario) {
+	a.pickle = s
+	a.scenario = a.probe.InitializeAuditor(s.Name, s.Tags)
+}
+
+func (a *ScenarioAuditor) beforeStep(st *godog.Step) {
+	// godog does not call AfterStep for undefined or skipped steps, so the previous step may still be in progress
+	a.scenario.SkipStep()
+	keyword, err := StepKeyword(a.pickle, st)
+	if err != nil {
+		log.Printf("[DEBUG] Unable to determine keyword for step '%s': %v", st.Text, err)
+	}
+	a.scenario.BeginStep(st.Text, keyword, stepArgument(st.Argument))
+}
+
+func (a *ScenarioAuditor) afterStep(st *godog.Step, err error) {
+	a.scenario.EndStep(err)
+}
+
+func (a *ScenarioAuditor) afterScenario(s *godog.Scenario, err error) {
+	a.scenario.SkipStep()
+}
+
+// stepArgument simplifies a gherkin doc string or data table for the audit output
+func stepArgument(arg *messages.PickleStepArgument) interface{} {
+	if arg == nil {
+		return nil
+	}
+	if doc := arg.GetDocString(); doc != nil {
+		return doc.Content
+	}
+	if table := arg.GetDataTable(); table != nil {
+		var rows [][]string
+		for _, row := range table.Rows {
+			var cells []string
+			for _, cell := range row.Cells {
+				cells = append(cells, cell.Value)
+			}
+			rows = append(rows, cells)
+		}
+		return rows
+	}
+	return nil
+}
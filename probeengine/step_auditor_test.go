@@ -0,0 +1,112 @@
+package probeengine
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/cucumber/godog"
+	"github.com/probr/probr-sdk/audit"
+)
+
+func TestAuditScenarioContext(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "auditor.feature")
+	content := []byte(`Feature: Automatic auditing
+  Scenario: Steps are audited
+    Given a precondition with a table:
+      | key   | value |
+      | alpha | one   |
+    When an action adds a payload
+    Then the outcome fails
+`)
+	if err := ioutil.WriteFile(path, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	probe := &audit.Probe{}
+	godog.TestSuite{
+		Name: "auditor",
+		ScenarioInitializer: func(ctx *godog.ScenarioContext) {
+			auditor := AuditScenarioContext(ctx, probe)
+			ctx.Step(`^a precondition with a table:$`, func(*godog.Table) error { return nil })
+			ctx.Step(`^an action adds a payload$`, func() error {
+				auditor.Scenario().AuditScenarioStep("", "adding a payload", "payload", nil)
+				return nil
+			})
+			ctx.Step(`^the outcome fails$`, func() error { return errors.New("outcome failed") })
+		},
+		Options: &godog.Options{Format: "progress", Output: ioutil.Discard, Paths: []string{path}},
+	}.Run()
+
+	s := probe.Scenarios[1]
+	if s == nil || len(s.Steps) != 3 {
+		t.Fatalf("Expected one scenario with three audited steps, found %v", s)
+	}
+	if s.Result != "Failed" {
+		t.Errorf("Scenario Result = %v, Expected: Failed", s.Result)
+	}
+	if s.Steps[1].Kind != audit.GivenStep || s.Steps[2].Kind != audit.WhenStep || s.Steps[3].Kind != audit.ThenStep {
+		t.Errorf("Unexpected step kinds: %v, %v, %v", s.Steps[1].Kind, s.Steps[2].Kind, s.Steps[3].Kind)
+	}
+	if rows, ok := s.Steps[1].Argument.([][]string); !ok || rows[1][0] != "alpha" {
+		t.Errorf("Expected data table argument, found %v", s.Steps[1].Argument)
+	}
+	if s.Steps[2].Payload != "payload" || s.Steps[2].Description != "adding a payload" {
+		t.Errorf("Expected payload to be added to the active step, found %v", s.Steps[2])
+	}
+	if s.Steps[3].Error != "outcome failed" || s.Steps[3].Duration == "" {
+		t.Errorf("Expected error and duration to be audited, found %v", s.Steps[3])
+	}
+}
+
+func TestAuditScenarioContext_UnfinishedSteps(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "unfinished.feature")
+	content := []byte(`Feature: Unfinished steps
+  Scenario: Undefined step
+    Given a step that passes
+    When a step that has no definition
+    Then a step that passes
+
+  Scenario: Skipped step
+    Given a step that passes
+    When a step that fails
+    Then a step that passes
+`)
+	if err := ioutil.WriteFile(path, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	probe := &audit.Probe{}
+	godog.TestSuite{
+		Name: "unfinished",
+		ScenarioInitializer: func(ctx *godog.ScenarioContext) {
+			AuditScenarioContext(ctx, probe)
+			ctx.Step(`^a step that passes$`, func() error { return nil })
+			ctx.Step(`^a step that fails$`, func() error { return errors.New("step failed") })
+		},
+		Options: &godog.Options{Format: "progress", Output: ioutil.Discard, Paths: []string{path}},
+	}.Run()
+
+	tests := []struct {
+		scenario int
+		steps    []string
+	}{
+		{1, []string{"Passed", "Undefined", "Skipped"}},
+		{2, []string{"Passed", "Failed", "Skipped"}},
+	}
+	for _, tt := range tests {
+		s := probe.Scenarios[tt.scenario]
+		if s == nil || len(s.Steps) != len(tt.steps) {
+			t.Fatalf("Expected scenario %d to have %d audited steps, found %v", tt.scenario, len(tt.steps), s)
+		}
+		if s.Result != "Failed" {
+			t.Errorf("Scenario '%s' Result = %v, Expected: Failed", s.Name, s.Result)
+		}
+		for i, result := range tt.steps {
+			if s.Steps[i+1].Result != result {
+				t.Errorf("Scenario '%s' step %d Result = %v, Expected: %v", s.Name, i+1, s.Steps[i+1].Result, result)
+			}
+		}
+	}
+}
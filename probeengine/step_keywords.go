@@ -0,0 +1,116 @@
+package probeengine
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/cucumber/gherkin-go/v11"
+	"github.com/cucumber/godog"
+	"github.com/cucumber/messages-go/v10"
+)
+
+// featureKeywords holds the parsed pickles for a feature file alongside the keyword for each gherkin step
+type featureKeywords struct {
+	pickles  []*messages.Pickle
+	keywords map[string]string // gherkin step ID -> keyword
+}
+
+var (
+	keywordCache     = make(map[string]*featureKeywords)
+	keywordCacheLock sync.Mutex
+)
+
+// StepKeyword returns the gherkin keyword (e.g. "Given ", "And ") used for a step in the provided scenario.
+// godog does not expose keywords on pickle steps, so the scenario's feature file is parsed to find them.
+// This is intended to be used from a godog BeforeStep hook alongside audit.Scenario.SetStepKeyword.
+func StepKeyword(scenario *godog.Scenario, st *godog.Step) (string, error) {
+	index := -1
+	for i, s := range scenario.Steps {
+		if s.Id == st.Id {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return "", fmt.Errorf("step '%s' is not part of scenario '%s'", st.Text, scenario.Name)
+	}
+
+	feature, err := getFeatureKeywords(scenario.Uri)
+	if err != nil {
+		return "", err
+	}
+	for _, pickle := range feature.pickles {
+		if matchingPickles(pickle, scenario) {
+			return feature.keywords[pickle.Steps[index].AstNodeIds[0]], nil
+		}
+	}
+	return "", fmt.Errorf("scenario '%s' could not be found in '%s'", scenario.Name, scenario.Uri)
+}
+
+// matchingPickles compares pickles by content, since IDs differ between godog's parse and ours
+func matchingPickles(a, b *messages.Pickle) bool {
+	if a.Name != b.Name || len(a.Steps) != len(b.Steps) {
+		return false
+	}
+	for i := range a.Steps {
+		if a.Steps[i].Text != b.Steps[i].Text {
+			return false
+		}
+	}
+	return true
+}
+
+func getFeatureKeywords(path string) (*featureKeywords, error) {
+	keywordCacheLock.Lock()
+	defer keywordCacheLock.Unlock()
+
+	if keywordCache[path] != nil {
+		return keywordCache[path], nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	newID := (&messages.Incrementing{}).NewId
+	doc, err := gherkin.ParseGherkinDocument(file, newID)
+	if err != nil {
+		return nil, fmt.Errorf("%s - %v", path, err)
+	}
+
+	feature := &featureKeywords{
+		pickles:  gherkin.Pickles(*doc, path, newID),
+		keywords: make(map[string]string),
+	}
+	if doc.Feature != nil {
+		collectKeywords(doc.Feature.Children, feature.keywords)
+	}
+	keywordCache[path] = feature
+	return feature, nil
+}
+
+func collectKeywords(children []*messages.GherkinDocument_Feature_FeatureChild, keywords map[string]string) {
+	var steps []*messages.GherkinDocument_Feature_Step
+	for _, child := range children {
+		switch {
+		case child.GetBackground() != nil:
+			steps = append(steps, child.GetBackground().Steps...)
+		case child.GetScenario() != nil:
+			steps = append(steps, child.GetScenario().Steps...)
+		case child.GetRule() != nil:
+			for _, ruleChild := range child.GetRule().Children {
+				if ruleChild.GetBackground() != nil {
+					steps = append(steps, ruleChild.GetBackground().Steps...)
+				} else if ruleChild.GetScenario() != nil {
+					steps = append(steps, ruleChild.GetScenario().Steps...)
+				}
+			}
+		}
+	}
+	for _, s := range steps {
+		keywords[s.Id] = s.Keyword
+	}
+}
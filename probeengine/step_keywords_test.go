@@ -0,0 +1,51 @@
+package probeengine
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cucumber/gherkin-go/v11"
+	"github.com/cucumber/messages-go/v10"
+)
+
+func TestStepKeyword(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keywords.feature")
+	content := []byte(`Feature: Keywords
+  Background:
+    Given a background step
+
+  Scenario: Multiple givens
+    Given a first precondition
+    And a second precondition
+    When an action is taken
+    Then an outcome is observed
+`)
+	if err := ioutil.WriteFile(path, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Simulate godog's parse, which uses different IDs to those generated by StepKeyword
+	file, _ := os.Open(path)
+	defer file.Close()
+	ids := &messages.Incrementing{}
+	ids.NewId()
+	ids.NewId()
+	doc, err := gherkin.ParseGherkinDocument(file, ids.NewId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scenario := gherkin.Pickles(*doc, path, ids.NewId)[0]
+
+	expected := []string{"Given ", "Given ", "And ", "When ", "Then "}
+	for i, st := range scenario.Steps {
+		got, err := StepKeyword(scenario, st)
+		if err != nil {
+			t.Errorf("StepKeyword() error = %v", err)
+		}
+		if got != expected[i] {
+			t.Errorf("StepKeyword() for '%s' = '%v', Expected: '%v'", st.Text, got, expected[i])
+		}
+	}
+}
@@ -2,58 +2,103 @@ package azure
 
 import (
 	"github.com/probr/probr-sdk/config"
+	azureconfig "github.com/probr/probr-sdk/providers/azure/config"
 	"github.com/probr/probr-sdk/utils"
 )
 
-// TenantID returns the azure Tenant in which the tests should be executed, configured by the user and may be set by the environment variable AZURE_TENANT_ID.
+// RegisterValidation requires the Azure credentials to be configured when config.GlobalOpts.Validate is run.
+// Service packs that use Azure should call this before config.GlobalConfig.Init, so that all missing
+// values are reported at startup rather than one at a time mid-probe.
+func RegisterValidation() {
+	config.RegisterValidation("azure", func(ctx *config.GlobalOpts) error {
+		return ctx.CloudProviders.Azure.Validate()
+	})
+}
+
+// Settings provides the Azure values of a single config. The package-level functions read config.GlobalConfig;
+// use SettingsFor with a Runtime's Config where more than one configuration is used in a single process.
+type Settings struct {
+	azure azureconfig.Azure
+}
+
+// SettingsFor returns the Azure values of the provided config
+func SettingsFor(c *config.GlobalOpts) Settings {
+	return Settings{azure: c.CloudProviders.Azure}
+}
+
+// TenantID returns Settings.TenantID for config.GlobalConfig
 func TenantID() (string, error) {
-	if config.GlobalConfig.CloudProviders.Azure.TenantID == "" {
-		return "", utils.ReformatError("Required config var not set: CloudProviders.Azure.TenantID")
-	}
-	return config.GlobalConfig.CloudProviders.Azure.TenantID, nil
+	return SettingsFor(&config.GlobalConfig).TenantID()
 }
 
-// ClientID returns the client (typically a service principal) that must be authorized for performing operations within the azure tenant, configured by the user and may be set by the environment variable AZURE_CLIENT_ID.
+// ClientID returns Settings.ClientID for config.GlobalConfig
 func ClientID() (string, error) {
-	if config.GlobalConfig.CloudProviders.Azure.ClientID == "" {
-		return "", utils.ReformatError("Required config var not set: CloudProviders.Azure.ClientID")
-	}
-	return config.GlobalConfig.CloudProviders.Azure.ClientID, nil
+	return SettingsFor(&config.GlobalConfig).ClientID()
 }
 
-// ClientSecret returns the client secret to allow client authetication and authorization, configured by the user and may be set by the environment variable AZURE_CLIENT_SECRET.
+// ClientSecret returns Settings.ClientSecret for config.GlobalConfig
 func ClientSecret() (string, error) {
-	if config.GlobalConfig.CloudProviders.Azure.ClientSecret == "" {
-		return "", utils.ReformatError("Required config var not set: CloudProviders.Azure.ClientSecret")
-	}
-	return config.GlobalConfig.CloudProviders.Azure.ClientSecret, nil
+	return SettingsFor(&config.GlobalConfig).ClientSecret()
 }
 
-// SubscriptionID returns the azure Subscription in which the tests should be executed, configured by the user and may be set by the environment variable AZURE_SUBSCRIPTION_ID.
+// SubscriptionID returns Settings.SubscriptionID for config.GlobalConfig
 func SubscriptionID() (string, error) {
-	if config.GlobalConfig.CloudProviders.Azure.SubscriptionID == "" {
-		return "", utils.ReformatError("Required config var not set: CloudProviders.Azure.SubscriptionID")
-	}
-	return config.GlobalConfig.CloudProviders.Azure.SubscriptionID, nil
+	return SettingsFor(&config.GlobalConfig).SubscriptionID()
 }
 
-// ResourceGroup returns the Probr user's azure resource group in which resurces should be created fpr testing, configured by the user and may be set by the environment variable AZURE_RESOURCE_GROUP.
+// ResourceGroup returns Settings.ResourceGroup for config.GlobalConfig
 func ResourceGroup() (string, error) {
-	if config.GlobalConfig.CloudProviders.Azure.ResourceGroup == "" {
-		return "", utils.ReformatError("Required config var not set: CloudProviders.Azure.ResourceGroup")
-	}
-	return config.GlobalConfig.CloudProviders.Azure.ResourceGroup, nil
+	return SettingsFor(&config.GlobalConfig).ResourceGroup()
 }
 
-// ResourceLocation returns the default location in which azure resources should be created, configured by the user and may be set by the environment variable AZURE_LOCATION.
+// ResourceLocation returns Settings.ResourceLocation for config.GlobalConfig
 func ResourceLocation() (string, error) {
-	if config.GlobalConfig.CloudProviders.Azure.ResourceLocation == "" {
-		return "", utils.ReformatError("Required config var not set: CloudProviders.Azure.ResourceLocation")
-	}
-	return config.GlobalConfig.CloudProviders.Azure.ResourceLocation, nil
+	return SettingsFor(&config.GlobalConfig).ResourceLocation()
 }
 
-// ManagementGroup returns an Azure Management Group which may be used for policy assignment, configured by the user and may be set by the environment variable AZURE_MANAGEMENT_GROUP.
+// ManagementGroup returns Settings.ManagementGroup for config.GlobalConfig
 func ManagementGroup() string {
-	return config.GlobalConfig.CloudProviders.Azure.ManagementGroup
+	return SettingsFor(&config.GlobalConfig).ManagementGroup()
+}
+
+// TenantID returns the azure Tenant in which the tests should be executed, configured by the user and may be set by the environment variable AZURE_TENANT_ID.
+func (s Settings) TenantID() (string, error) {
+	return required(s.azure.TenantID, "TenantID")
+}
+
+// ClientID returns the client (typically a service principal) that must be authorized for performing operations within the azure tenant, configured by the user and may be set by the environment variable AZURE_CLIENT_ID.
+func (s Settings) ClientID() (string, error) {
+	return required(s.azure.ClientID, "ClientID")
+}
+
+// ClientSecret returns the client secret to allow client authetication and authorization, configured by the user and may be set by the environment variable AZURE_CLIENT_SECRET.
+func (s Settings) ClientSecret() (string, error) {
+	return required(s.azure.ClientSecret, "ClientSecret")
+}
+
+// SubscriptionID returns the azure Subscription in which the tests should be executed, configured by the user and may be set by the environment variable AZURE_SUBSCRIPTION_ID.
+func (s Settings) SubscriptionID() (string, error) {
+	return required(s.azure.SubscriptionID, "SubscriptionID")
+}
+
+// ResourceGroup returns the Probr user's azure resource group in which resurces should be created fpr testing, configured by the user and may be set by the environment variable AZURE_RESOURCE_GROUP.
+func (s Settings) ResourceGroup() (string, error) {
+	return required(s.azure.ResourceGroup, "ResourceGroup")
+}
+
+// ResourceLocation returns the default location in which azure resources should be created, configured by the user and may be set by the environment variable AZURE_LOCATION.
+func (s Settings) ResourceLocation() (string, error) {
+	return required(s.azure.ResourceLocation, "ResourceLocation")
+}
+
+// ManagementGroup returns an Azure Management Group which may be used for policy assignment, configured by the user and may be set by the environment variable AZURE_MANAGEMENT_GROUP.
+func (s Settings) ManagementGroup() string {
+	return s.azure.ManagementGroup
+}
+
+func required(value, name string) (string, error) {
+	if value == "" {
+		return "", utils.ReformatError("Required config var not set: CloudProviders.Azure.%s", name)
+	}
+	return value, nil
 }
@@ -1,25 +1,33 @@
 package azureconfig
 
-import "github.com/probr/probr-sdk/config/setter"
+import (
+	"strconv"
+
+	"github.com/probr/probr-sdk/config/setter"
+	"github.com/probr/probr-sdk/config/validator"
+)
 
 // Azure config options that may be required by any service pack
 type Azure struct {
-	Excluded         string `yaml:"Excluded"`
-	TenantID         string `yaml:"TenantID"`
-	SubscriptionID   string `yaml:"SubscriptionID"`
-	ClientID         string `yaml:"ClientID"`
-	ClientSecret     string `yaml:"ClientSecret"`
-	ResourceGroup    string `yaml:"ResourceGroup"`
-	ResourceLocation string `yaml:"ResourceLocation"`
-	ManagementGroup  string `yaml:"ManagementGroup"`
+	Excluded         string `yaml:"Excluded" doc:"Set to true if Azure is not used, to skip validation of the Azure values"`
+	TenantID         string `yaml:"TenantID" env:"PROBR_AZURE_TENANT_ID" validate:"required,uuid"`
+	SubscriptionID   string `yaml:"SubscriptionID" env:"PROBR_AZURE_SUBSCRIPTION_ID" validate:"required,uuid"`
+	ClientID         string `yaml:"ClientID" env:"PROBR_AZURE_CLIENT_ID" validate:"required,uuid"`
+	ClientSecret     string `yaml:"ClientSecret" env:"PROBR_AZURE_CLIENT_SECRET" validate:"required" secret:"true"`
+	ResourceGroup    string `yaml:"ResourceGroup" env:"PROBR_AZURE_RESOURCE_GROUP"`
+	ResourceLocation string `yaml:"ResourceLocation" env:"PROBR_AZURE_RESOURCE_LOCATION"`
+	ManagementGroup  string `yaml:"ManagementGroup" doc:"May be used for policy assignment"`
 }
 
 // SetEnvAndDefaults will associate ENV variables and default values to each Azure field
-func (ctx *Azure) SetEnvAndDefaults() {
-	setter.SetVar(&ctx.TenantID, "PROBR_AZURE_TENANT_ID", "")
-	setter.SetVar(&ctx.SubscriptionID, "PROBR_AZURE_SUBSCRIPTION_ID", "")
-	setter.SetVar(&ctx.ClientID, "PROBR_AZURE_CLIENT_ID", "")
-	setter.SetVar(&ctx.ClientSecret, "PROBR_AZURE_CLIENT_SECRET", "")
-	setter.SetVar(&ctx.ResourceGroup, "PROBR_AZURE_RESOURCE_GROUP", "")
-	setter.SetVar(&ctx.ResourceLocation, "PROBR_AZURE_RESOURCE_LOCATION", "")
+func (ctx *Azure) SetEnvAndDefaults() error {
+	return setter.SetVars(ctx)
+}
+
+// Validate returns every missing or malformed Azure value, unless Azure has been excluded
+func (ctx *Azure) Validate() error {
+	if excluded, _ := strconv.ParseBool(ctx.Excluded); excluded {
+		return nil
+	}
+	return validator.Validate(ctx)
 }
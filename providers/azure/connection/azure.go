@@ -52,65 +52,72 @@ var instance *AzureConnection
 var once sync.Once
 
 // NewAzureConnection provides a singleton instance of AzureConnection. Initializes all internal clients to interact with Azure.
+// Use CreateAzureConnection where more than one configuration is needed in a single process.
 func NewAzureConnection(c context.Context, subscriptionID, tenantID, clientID, clientSecret string) *AzureConnection {
 	once.Do(func() {
-		// Guard clause
-		if c == nil {
-			instance.isCloudAvailable = utils.ReformatError("Context instance cannot be nil")
-			return
-		}
-
-		instance = &AzureConnection{
-			ctx: c,
-			credentials: AzureCredentials{
-				SubscriptionID: subscriptionID,
-				TenantID:       tenantID,
-				ClientID:       clientID,
-				ClientSecret:   clientSecret,
-			},
-		}
-
-		// Create an authorization object via the connection config vars
-		clientCredentialsConfig := auth.NewClientCredentialsConfig(clientID, clientSecret, tenantID)
-		authorizer, authErr := clientCredentialsConfig.Authorizer()
-		if authErr == nil {
-			instance.credentials.Authorizer = authorizer
-		} else {
-			instance.isCloudAvailable = utils.ReformatError("Failed to initialize Azure Authorizer: %v", authErr)
-			return
-		}
-
-		// Create an azure resource group client object via the connection config vars
-		var grpErr error
-		instance.ResourceGroup, grpErr = NewResourceGroup(c, instance.credentials)
-		if grpErr != nil {
-			instance.isCloudAvailable = utils.ReformatError("Failed to initialize Azure Resource Group: %v", grpErr)
-			return
-		}
-
-		// Create an azure resource group client object via the connection config vars
-		var saErr error
-		instance.StorageAccount, grpErr = NewStorageAccount(c, instance.credentials)
-		if saErr != nil {
-			instance.isCloudAvailable = utils.ReformatError("Failed to initialize Azure Storage Account: %v", grpErr)
-			return
-		}
-
-		var csErr error
-		instance.ManagedCluster, csErr = NewContainerService(c, instance.credentials)
-		if csErr != nil {
-			instance.isCloudAvailable = utils.ReformatError("Failed to initialize Azure Kubernetes Service: %v", grpErr)
-		}
-
-		var dskErr error
-		instance.Disk, dskErr = NewDisk(c, instance.credentials)
-		if dskErr != nil {
-			instance.isCloudAvailable = utils.ReformatError("Failed to initialize Azure Disk: %v", grpErr)
-		}
+		instance = CreateAzureConnection(c, subscriptionID, tenantID, clientID, clientSecret)
 	})
 	return instance
 }
 
+// CreateAzureConnection initializes a new AzureConnection on every call, along with all internal clients to interact with Azure
+func CreateAzureConnection(c context.Context, subscriptionID, tenantID, clientID, clientSecret string) (conn *AzureConnection) {
+	conn = &AzureConnection{
+		ctx: c,
+		credentials: AzureCredentials{
+			SubscriptionID: subscriptionID,
+			TenantID:       tenantID,
+			ClientID:       clientID,
+			ClientSecret:   clientSecret,
+		},
+	}
+
+	// Guard clause
+	if c == nil {
+		conn.isCloudAvailable = utils.ReformatError("Context instance cannot be nil")
+		return
+	}
+
+	// Create an authorization object via the connection config vars
+	clientCredentialsConfig := auth.NewClientCredentialsConfig(clientID, clientSecret, tenantID)
+	authorizer, authErr := clientCredentialsConfig.Authorizer()
+	if authErr == nil {
+		conn.credentials.Authorizer = authorizer
+	} else {
+		conn.isCloudAvailable = utils.ReformatError("Failed to initialize Azure Authorizer: %v", authErr)
+		return
+	}
+
+	// Create an azure resource group client object via the connection config vars
+	var grpErr error
+	conn.ResourceGroup, grpErr = NewResourceGroup(c, conn.credentials)
+	if grpErr != nil {
+		conn.isCloudAvailable = utils.ReformatError("Failed to initialize Azure Resource Group: %v", grpErr)
+		return
+	}
+
+	// Create an azure resource group client object via the connection config vars
+	var saErr error
+	conn.StorageAccount, grpErr = NewStorageAccount(c, conn.credentials)
+	if saErr != nil {
+		conn.isCloudAvailable = utils.ReformatError("Failed to initialize Azure Storage Account: %v", grpErr)
+		return
+	}
+
+	var csErr error
+	conn.ManagedCluster, csErr = NewContainerService(c, conn.credentials)
+	if csErr != nil {
+		conn.isCloudAvailable = utils.ReformatError("Failed to initialize Azure Kubernetes Service: %v", grpErr)
+	}
+
+	var dskErr error
+	conn.Disk, dskErr = NewDisk(c, conn.credentials)
+	if dskErr != nil {
+		conn.isCloudAvailable = utils.ReformatError("Failed to initialize Azure Disk: %v", grpErr)
+	}
+	return
+}
+
 // IsCloudAvailable verifies that the connection instantiation did not report a failure
 func (az *AzureConnection) IsCloudAvailable() error {
 	return az.isCloudAvailable
@@ -1,28 +1,73 @@
 package kubernetesconfig
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 
+	"github.com/probr/probr-sdk/config"
 	"github.com/probr/probr-sdk/config/setter"
+	"github.com/probr/probr-sdk/config/validator"
+	"gopkg.in/yaml.v2"
 )
 
+// SectionKey is the vars file key beneath which the Kubernetes config is decoded when registered via Register
+const SectionKey = config.ProvidersNamespace + ".Kubernetes"
+
 // Kubernetes contains common variables needed when using the Kubernetes provider
 type Kubernetes struct {
-	KeepPods                 string `yaml:"KeepPods"`
-	KubeConfigPath           string `yaml:"KubeConfig"`
-	KubeContext              string `yaml:"KubeContext"`
-	AuthorisedContainerImage string `yaml:"AuthorisedContainerImage"`
-	ProbeNamespace           string `yaml:"ProbeNamespace"`
+	KeepPods                 bool   `yaml:"KeepPods" env:"PROBR_KEEP_PODS" default:"false" doc:"Leave pods created by probes running for debugging"`
+	KubeConfigPath           string `yaml:"KubeConfig" validate:"path" doc:"Defaults to KUBE_CONFIG or ~/.kube/config"`
+	KubeContext              string `yaml:"KubeContext" env:"KUBE_CONTEXT"`
+	AuthorisedContainerImage string `yaml:"AuthorisedContainerImage" env:"PROBR_AUTHORISED_IMAGE" doc:"Image from an authorised registry, used when creating pods"`
+	ProbeNamespace           string `yaml:"ProbeNamespace" env:"PROBR_K8S_PROBE_NAMESPACE" default:"probr-general-test-ns" validate:"required"`
 }
 
 // SetEnvAndDefaults will set value from os.Getenv and default to the specified value
-func (ctx *Kubernetes) SetEnvAndDefaults() {
-	setter.SetVar(&ctx.KeepPods, "PROBR_KEEP_PODS", "false")
-	setter.SetVar(&ctx.KubeConfigPath, "KUBE_CONFIG", getDefaultKubeConfigPath())
-	setter.SetVar(&ctx.KubeContext, "KUBE_CONTEXT", "")
-	setter.SetVar(&ctx.AuthorisedContainerImage, "PROBR_AUTHORISED_IMAGE", "")
-	setter.SetVar(&ctx.ProbeNamespace, "PROBR_K8S_PROBE_NAMESPACE", "probr-general-test-ns")
+func (ctx *Kubernetes) SetEnvAndDefaults() error {
+	err := setter.SetVars(ctx)
+	if err != nil {
+		return err
+	}
+	return setter.SetVar(&ctx.KubeConfigPath, "KUBE_CONFIG", getDefaultKubeConfigPath())
+}
+
+// UnmarshalYAML decodes the config, also accepting KeepPods as a quoted string such as "true",
+// as it was a string in earlier versions of the SDK
+func (ctx *Kubernetes) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var values map[string]interface{}
+	if err := unmarshal(&values); err != nil {
+		return err
+	}
+	if s, ok := values["KeepPods"].(string); ok {
+		if s == "" {
+			delete(values, "KeepPods")
+		} else {
+			keep, err := strconv.ParseBool(s)
+			if err != nil {
+				return fmt.Errorf("invalid value '%s' for KeepPods: %v", s, err)
+			}
+			values["KeepPods"] = keep
+		}
+	}
+	data, err := yaml.Marshal(values)
+	if err != nil {
+		return err
+	}
+	type plain Kubernetes
+	return yaml.Unmarshal(data, (*plain)(ctx))
+}
+
+// Register adds a Kubernetes config section to config.GlobalConfig, to be populated when config.GlobalConfig.Init is run
+func Register() (*Kubernetes, error) {
+	k8s := &Kubernetes{}
+	return k8s, config.RegisterSection(SectionKey, k8s)
+}
+
+// Validate returns every missing or malformed Kubernetes value
+func (ctx *Kubernetes) Validate() error {
+	return validator.Validate(ctx)
 }
 
 func getDefaultKubeConfigPath() string {
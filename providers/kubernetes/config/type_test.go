@@ -0,0 +1,32 @@
+package kubernetesconfig
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestKubernetes_UnmarshalYAML(t *testing.T) {
+	tests := []struct {
+		yaml     string
+		expected bool
+		err      bool
+	}{
+		{yaml: "KeepPods: true", expected: true},
+		{yaml: `KeepPods: "true"`, expected: true},
+		{yaml: `KeepPods: "false"`, expected: false},
+		{yaml: `KeepPods: ""`, expected: false},
+		{yaml: `KeepPods: "sometimes"`, err: true},
+	}
+	for _, tt := range tests {
+		k8s := Kubernetes{ProbeNamespace: "existing"}
+		err := yaml.Unmarshal([]byte(tt.yaml+"\nKubeContext: ctx"), &k8s)
+		if (err != nil) != tt.err {
+			t.Errorf("Unmarshal(%s) error = %v, expected an error: %v", tt.yaml, err, tt.err)
+			continue
+		}
+		if !tt.err && (k8s.KeepPods != tt.expected || k8s.KubeContext != "ctx" || k8s.ProbeNamespace != "existing") {
+			t.Errorf("Unmarshal(%s) = %+v, expected KeepPods: %v", tt.yaml, k8s, tt.expected)
+		}
+	}
+}
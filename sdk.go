@@ -1 +1,116 @@
+// Package sdk provides Runtime, which carries the state used while running probes.
 package sdk
+
+import (
+	"context"
+	"sync"
+
+	hclog "github.com/hashicorp/go-hclog"
+
+	"github.com/probr/probr-sdk/audit"
+	"github.com/probr/probr-sdk/config"
+	"github.com/probr/probr-sdk/logging"
+	"github.com/probr/probr-sdk/probeengine"
+	"github.com/probr/probr-sdk/providers/azure"
+	azureconnection "github.com/probr/probr-sdk/providers/azure/connection"
+	kubernetesconfig "github.com/probr/probr-sdk/providers/kubernetes/config"
+	kubernetesconnection "github.com/probr/probr-sdk/providers/kubernetes/connection"
+)
+
+// Runtime carries the config, logger, summary and provider connections used by a service pack.
+// Creating a Runtime for each configuration allows several to be used in one process, such as in parallel tests.
+// Default wraps the package-level globals for backwards compatibility.
+type Runtime struct {
+	Name    string
+	Config  *config.GlobalOpts
+	Logger  hclog.Logger
+	Summary *audit.SummaryState
+
+	isDefault  bool
+	mux        sync.Mutex
+	azure      *azureconnection.AzureConnection
+	kubernetes *kubernetesconnection.Conn
+}
+
+var (
+	defaultRuntime *Runtime
+	defaultOnce    sync.Once
+)
+
+// NewRuntime creates a Runtime that shares no state with the package-level globals or any other Runtime.
+// The config should already have been initialized via Init.
+func NewRuntime(name string, c *config.GlobalOpts) *Runtime {
+	summary := audit.NewSummaryState(name)
+	summary.SetConfig(c)
+	return &Runtime{
+		Name:    name,
+		Config:  c,
+		Logger:  logging.NewLogger(c.LogLevel, logging.Output(c.LogFile), c.JSONLogs()).Named(name),
+		Summary: &summary,
+	}
+}
+
+// Default returns a Runtime backed by config.GlobalConfig, the default logger from the logging package
+// and the shared Azure connection. The default logger is configured from config.GlobalConfig when Default is first
+// called, which should be after config.GlobalConfig.Init.
+func Default() *Runtime {
+	defaultOnce.Do(func() {
+		summary := audit.NewSummaryState("")
+		defaultRuntime = &Runtime{
+			Config:    &config.GlobalConfig,
+			Logger:    logging.Configure(&config.GlobalConfig),
+			Summary:   &summary,
+			isDefault: true,
+		}
+	})
+	return defaultRuntime
+}
+
+// Tags returns the godog tag expression for the runtime's tag inclusions and exclusions
+func (r *Runtime) Tags() string {
+	return config.ParseTags(r.Config.TagInclusions, r.Config.TagExclusions)
+}
+
+// NewProbeStore creates a ProbeStore that uses the runtime's config, logger and summary
+func (r *Runtime) NewProbeStore() *probeengine.ProbeStore {
+	store := probeengine.NewProbeStore(r.Name, r.Tags(), r.Summary)
+	store.Config = r.Config
+	store.Logger = r.Logger
+	return store
+}
+
+// Azure returns the runtime's connection to Azure, creating it from the runtime's config on first use
+func (r *Runtime) Azure(ctx context.Context) *azureconnection.AzureConnection {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if r.azure == nil {
+		az := r.Config.CloudProviders.Azure
+		if r.isDefault {
+			r.azure = azureconnection.NewAzureConnection(ctx, az.SubscriptionID, az.TenantID, az.ClientID, az.ClientSecret)
+		} else {
+			r.azure = azureconnection.CreateAzureConnection(ctx, az.SubscriptionID, az.TenantID, az.ClientID, az.ClientSecret)
+		}
+	}
+	return r.azure
+}
+
+// AzureSettings returns the Azure values of the runtime's config, in place of the package-level functions
+// such as azure.TenantID, which read config.GlobalConfig
+func (r *Runtime) AzureSettings() azure.Settings {
+	return azure.SettingsFor(r.Config)
+}
+
+// CleanupTmp removes the runtime's TmpDir, in place of probeengine.CleanupTmp, which removes that of config.GlobalConfig
+func (r *Runtime) CleanupTmp() {
+	r.Config.CleanupTmp()
+}
+
+// Kubernetes returns the runtime's connection to a cluster, creating it from the provided config on first use
+func (r *Runtime) Kubernetes(k8s *kubernetesconfig.Kubernetes) *kubernetesconnection.Conn {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if r.kubernetes == nil {
+		r.kubernetes = kubernetesconnection.NewConnection(k8s.KubeConfigPath, k8s.KubeContext, k8s.ProbeNamespace)
+	}
+	return r.kubernetes
+}
@@ -0,0 +1,73 @@
+package sdk
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/probr/probr-sdk/audit"
+	"github.com/probr/probr-sdk/config"
+)
+
+func newTestRuntime(t *testing.T, name string) *Runtime {
+	c := &config.GlobalOpts{WriteDirectory: t.TempDir(), TagInclusions: []string{name}}
+	c.Init()
+	return NewRuntime(name, c)
+}
+
+func TestNewRuntime_Isolated(t *testing.T) {
+	first := newTestRuntime(t, "first")
+	second := newTestRuntime(t, "second")
+
+	for _, r := range []*Runtime{first, second} {
+		r.Summary.GetProbeLog("probe").Result = "Success"
+		r.Summary.WriteSummary()
+		if _, err := os.Stat(filepath.Join(r.Config.WriteDirectory, "summary.json")); err != nil {
+			t.Errorf("Summary for runtime '%s' was not written to its own WriteDirectory: %v", r.Name, err)
+		}
+		store := r.NewProbeStore()
+		if store.Config != r.Config || store.Logger != r.Logger || store.Summary != r.Summary || store.Tags != "@"+r.Name {
+			t.Errorf("ProbeStore for runtime '%s' does not use the runtime's state: %+v", r.Name, store)
+		}
+	}
+	first.Config.CloudProviders.Azure.TenantID = "first-tenant"
+	if tenant, err := first.AzureSettings().TenantID(); err != nil || tenant != "first-tenant" {
+		t.Errorf("AzureSettings() should use the runtime's config, got %s, %v", tenant, err)
+	}
+	if _, err := second.AzureSettings().TenantID(); err == nil {
+		t.Errorf("Expected an error for a runtime without a TenantID")
+	}
+
+	tmp := filepath.Join(t.TempDir(), "tmp")
+	os.Mkdir(tmp, 0755)
+	first.Config.TmpDir = tmp
+	first.CleanupTmp()
+	if _, err := os.Stat(tmp); !os.IsNotExist(err) {
+		t.Errorf("CleanupTmp() should remove the runtime's TmpDir, got %v", err)
+	}
+
+	if first.Summary == second.Summary || first.Logger == second.Logger {
+		t.Errorf("Runtimes should not share a summary or logger")
+	}
+
+	firstAzure, secondAzure := first.Azure(nil), second.Azure(nil)
+	if firstAzure == secondAzure || first.Azure(nil) != firstAzure {
+		t.Errorf("Each runtime should hold its own Azure connection")
+	}
+	if firstAzure.IsCloudAvailable() == nil {
+		t.Errorf("Expected an error for a connection created without a context")
+	}
+}
+
+func TestDefault(t *testing.T) {
+	r := Default()
+	if r != Default() || r.Config != &config.GlobalConfig {
+		t.Errorf("Default() should return a single runtime backed by config.GlobalConfig")
+	}
+	// The default summary falls back to the global config when no config is set
+	r.Summary.AddSink(&audit.StdoutSink{Writer: ioutil.Discard})
+	if store := r.NewProbeStore(); store.Config != &config.GlobalConfig {
+		t.Errorf("Default ProbeStore should use config.GlobalConfig")
+	}
+}
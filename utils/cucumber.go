@@ -1,23 +1,34 @@
 package utils
 
 import (
-	"strings"
+	"log"
+
+	"github.com/probr/probr-sdk/config/tags"
 )
 
 // CucumberTagsListToString will parse the tags specified in Vars.Tags
-func CucumberTagsListToString(tags []string) string {
-	var tagList []string
-	for _, tag := range tags {
-		tagList = append(tagList, "@"+tag)
-	}
-	return strings.Join(tagList, ",")
+//
+// Deprecated: use config.ParseTags, or build an expression via the tags package and convert it with tags.Godog
+func CucumberTagsListToString(list []string) string {
+	s, _ := tags.Godog(tags.Or(tagList(list)...)) // The tags are joined into a single group, so the limit cannot be reached
+	return s
 }
 
 // CucumberTagExclusionsListToString tag exclusions provided via the config vars file
-func CucumberTagExclusionsListToString(tags []string) string {
-	var tagList []string
-	for _, tag := range tags {
-		tagList = append(tagList, "~@"+tag)
+//
+// Deprecated: use config.ParseTags, or build an expression via the tags package and convert it with tags.Godog
+func CucumberTagExclusionsListToString(list []string) string {
+	s, err := tags.Godog(tags.Not(tags.Or(tagList(list)...)))
+	if err != nil {
+		log.Printf("[ERROR] Ignoring tag exclusions. %v", err)
+	}
+	return s
+}
+
+func tagList(list []string) []tags.Expr {
+	expressions := make([]tags.Expr, len(list))
+	for i, tag := range list {
+		expressions[i] = tags.Tag(tag)
 	}
-	return strings.Join(tagList, " && ")
+	return expressions
 }